@@ -0,0 +1,65 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"swift-parser/internal/database"
+	"swift-parser/internal/diff"
+	"swift-parser/internal/parser"
+)
+
+// runDiff implements the diff subcommand, which compares a directory file
+// with the database without changing it
+func runDiff(args []string) {
+	flags := flag.NewFlagSet("diff", flag.ExitOnError)
+	dsn := flags.String("dsn", "", dsnUsage)
+	sheet := flags.String("sheet", "", "Excel sheet to compare (default first sheet)")
+	format := flags.String("format", "text", "output format: text or json")
+	all := flags.Bool("all", false, "list unchanged codes too")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s diff [flags] file\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	if *format != "text" && *format != "json" {
+		log.Fatalf("⚠️ -format must be text or json, got %q", *format)
+	}
+
+	input := flags.Arg(0)
+	codes, report, err := parser.ParseFile(input, parser.Options{Sheet: *sheet})
+	if err != nil {
+		log.Fatalf("⚠️ Failed to parse %s: %v", input, err)
+	}
+	log.Printf("📄 %s: %s", input, report.Summary())
+	for _, issue := range report.Rejected {
+		log.Printf("⚠️ %s", issue)
+	}
+
+	db, err := database.NewDB(connString(*dsn))
+	if err != nil {
+		log.Fatalf("⚠️ Database connection failed: %v", err)
+	}
+	defer db.Close()
+
+	current, err := db.ListSWIFTCodes()
+	if err != nil {
+		log.Fatalf("⚠️ Failed to read SWIFT codes: %v", err)
+	}
+
+	result := diff.Compare(current, codes)
+	if *format == "json" {
+		err = result.WriteJSON(os.Stdout, *all)
+	} else {
+		err = result.WriteText(os.Stdout, *all)
+	}
+	if err != nil {
+		log.Fatalf("⚠️ Failed to write diff: %v", err)
+	}
+}
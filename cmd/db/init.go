@@ -2,26 +2,98 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"log"
 	"os"
+	"os/user"
 	"path/filepath"
+	"strings"
 	"swift-parser/internal/database"
+	"swift-parser/internal/models"
 	"swift-parser/internal/parser"
 	"time"
 
 	"github.com/joho/godotenv"
 )
 
-func main() {
-	log.Println("Starting database initialization...")
+// defaultInput is loaded when no input file is given
+var defaultInput = filepath.Join("internal", "parser", "testdata", "Interns_2025_SWIFT_CODES.xlsx")
 
-	if err := godotenv.Load(); err != nil {
-		log.Fatal("⚠️ Error loading .env file")
+// config holds the initializer's command-line options
+type config struct {
+	inputs    []string
+	sheet     string
+	migrate   bool
+	dsn       string
+	dryRun    bool
+	truncate  bool
+	batchSize int
+	strict    bool
+
+	sync              bool
+	syncCountries     []string
+	maxRemovedPercent float64
+}
+
+// stringList is a flag.Value collecting repeated or comma-separated values
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, ",") }
+
+func (l *stringList) Set(value string) error {
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			*l = append(*l, v)
+		}
 	}
-	log.Println("✅ Environment variables loaded")
+	return nil
+}
+
+func parseFlags(args []string) config {
+	var cfg config
+	var inputs, countries stringList
+
+	flags := flag.NewFlagSet("load", flag.ExitOnError)
+	flags.Var(&inputs, "input", "input file (.xlsx, .csv or .tsv); repeat or comma-separate for several (default "+defaultInput+")")
+	flags.StringVar(&cfg.sheet, "sheet", "", "Excel sheet to load (default first sheet)")
+	flags.BoolVar(&cfg.migrate, "migrate", true, "apply pending schema migrations before loading")
+	flags.StringVar(&cfg.dsn, "dsn", "", dsnUsage)
+	flags.BoolVar(&cfg.dryRun, "dry-run", false, "parse and report without connecting to the database")
+	flags.BoolVar(&cfg.truncate, "truncate", false, "delete all SWIFT codes before loading")
+	flags.IntVar(&cfg.batchSize, "batch-size", database.DefaultBulkBatchSize, "number of rows copied per transaction")
+	flags.BoolVar(&cfg.strict, "strict", false, "abort without loading anything if any row is rejected")
+	flags.BoolVar(&cfg.sync, "sync", false, "delete stored codes missing from the input files")
+	flags.Var(&countries, "sync-country", "limit -sync to these ISO2 countries; repeat or comma-separate (default all)")
+	flags.Float64Var(&cfg.maxRemovedPercent, "max-removed-percent", database.DefaultMaxRemovedPercent,
+		"refuse a sync removing more than this percentage of the codes in scope")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s [flags] [file ...]\n       %s migrate [up|down|version] [flags]\n       %s purge [flags]\n       %s diff [flags] file\n", os.Args[0], os.Args[0], os.Args[0], os.Args[0])
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
 
-	connStr := fmt.Sprintf(
+	cfg.inputs = append(inputs, flags.Args()...)
+	for _, country := range countries {
+		cfg.syncCountries = append(cfg.syncCountries, strings.ToUpper(country))
+	}
+	if len(cfg.inputs) == 0 {
+		cfg.inputs = []string{defaultInput}
+	}
+	return cfg
+}
+
+const dsnUsage = "PostgreSQL connection string (default DATABASE_URL or DB_HOST, DB_PORT, DB_USER, DB_PASSWORD, DB_NAME)"
+
+// connString returns the -dsn flag, falling back to the environment
+func connString(dsn string) string {
+	if dsn != "" {
+		return dsn
+	}
+	if url := os.Getenv("DATABASE_URL"); url != "" {
+		return url
+	}
+	return fmt.Sprintf(
 		"host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
 		os.Getenv("DB_HOST"),
 		os.Getenv("DB_PORT"),
@@ -29,41 +101,178 @@ func main() {
 		os.Getenv("DB_PASSWORD"),
 		os.Getenv("DB_NAME"),
 	)
+}
+
+func main() {
+	if err := godotenv.Load(); err != nil {
+		log.Println("⚠️ No .env file found, using environment variables")
+	} else {
+		log.Println("✅ Environment variables loaded")
+	}
 
-	db, err := database.NewDB(connStr)
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrate(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "purge" {
+		runPurge(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "diff" {
+		runDiff(os.Args[2:])
+		return
+	}
+
+	cfg := parseFlags(os.Args[1:])
+	if cfg.batchSize <= 0 {
+		log.Fatalf("⚠️ -batch-size must be positive, got %d", cfg.batchSize)
+	}
+	if cfg.sync && cfg.truncate {
+		log.Fatalf("⚠️ -sync and -truncate cannot be combined")
+	}
+	if !cfg.sync && len(cfg.syncCountries) > 0 {
+		log.Fatalf("⚠️ -sync-country requires -sync")
+	}
+	if cfg.maxRemovedPercent < 0 || cfg.maxRemovedPercent > 100 {
+		log.Fatalf("⚠️ -max-removed-percent must be between 0 and 100, got %g", cfg.maxRemovedPercent)
+	}
+
+	log.Println("Starting database initialization...")
+
+	ctx := context.Background()
+	opts := parser.Options{Sheet: cfg.sheet}
+
+	// Validate every file before touching the database in dry-run and
+	// strict mode
+	if cfg.dryRun || cfg.strict {
+		rejected := 0
+		for _, input := range cfg.inputs {
+			report, err := parser.Iterate(ctx, input, opts, func(models.SwiftCode) error {
+				return nil
+			})
+			if err != nil {
+				log.Fatalf("⚠️ Failed to parse %s: %v", input, err)
+			}
+			log.Printf("📄 %s", input)
+			printReport(report)
+			rejected += len(report.Rejected)
+		}
+
+		if cfg.strict && rejected > 0 {
+			log.Fatalf("⚠️ Strict mode: %d rows rejected, nothing loaded", rejected)
+		}
+		if cfg.dryRun {
+			log.Println("🎉 Dry run complete, database not modified")
+			return
+		}
+	}
+
+	db, err := database.NewDB(connString(cfg.dsn))
 	if err != nil {
 		log.Fatalf("⚠️ Database connection failed: %v", err)
 	}
 	defer db.Close()
 	log.Println("✅ Connected to database")
 
-	schemaPath := filepath.Join("internal", "database", "schema.sql")
-	schemaSQL, err := os.ReadFile(schemaPath)
-	if err != nil {
-		log.Fatalf("⚠️ Failed to read schema file: %v", err)
+	if cfg.migrate {
+		applied, err := db.MigrateUp(ctx)
+		if err != nil {
+			log.Fatalf("⚠️ Failed to migrate schema: %v", err)
+		}
+		for _, m := range applied {
+			log.Printf("✅ Applied migration %d_%s", m.Version, m.Name)
+		}
+	}
+	if err := db.CheckSchemaVersion(ctx); err != nil {
+		log.Fatalf("⚠️ Schema check failed: %v", err)
 	}
 
-	_, err = db.Exec(string(schemaSQL))
-	if err != nil {
-		log.Fatalf("⚠️ Failed to create schema: %v", err)
+	actor := currentUser()
+	if cfg.sync {
+		syncInputs(ctx, db, cfg, opts, actor)
+		log.Println("🎉 Database initialization complete!")
+		return
 	}
-	log.Println("✅ Database schema created")
 
-	// Parse and insert Excel data
-	start := time.Now()
-	excelPath := filepath.Join("internal", "parser", "testdata", "Interns_2025_SWIFT_CODES.xlsx")
-	codes, err := parser.ParseExcelFile(excelPath)
-	if err != nil {
-		log.Fatalf("⚠️ Failed to parse Excel file: %v", err)
+	// Batches are committed one at a time, except after -truncate: then the
+	// removal and every batch share one transaction, so a failed load
+	// leaves the existing codes in place.
+	load := func(ctx context.Context, batch []models.SwiftCode) (database.BulkResult, error) {
+		return db.BulkLoad(ctx, batch, database.BulkOptions{BatchSize: cfg.batchSize})
+	}
+	var tx *database.Load
+	if cfg.truncate {
+		truncateCtx := database.WithAudit(ctx, database.Audit{Actor: actor, Source: database.SourceCLI})
+		tx, err = db.BeginLoad(truncateCtx)
+		if err != nil {
+			log.Fatalf("⚠️ Failed to start load: %v", err)
+		}
+		if err := tx.Truncate(truncateCtx); err != nil {
+			tx.Rollback()
+			log.Fatalf("⚠️ Failed to truncate SWIFT codes: %v", err)
+		}
+		log.Println("✅ Existing SWIFT codes removed (kept until the load commits)")
+		load = tx.Load
 	}
-	log.Printf("✅ Parsed %d SWIFT codes from Excel", len(codes))
 
-	// Insert data into database
-	ctx := context.Background()
-	if err := db.InsertSwiftCodes(ctx, codes); err != nil {
-		log.Fatalf("⚠️ Failed to insert SWIFT codes: %v", err)
+	start := time.Now()
+	var total database.BulkResult
+	for _, input := range cfg.inputs {
+		loadCtx := database.WithAudit(ctx, database.Audit{
+			Actor:  actor,
+			Source: database.ImportSource(filepath.Base(input)),
+		})
+
+		// Stream the input file into the database in batches
+		report, err := parser.IterateBatches(ctx, input, opts, cfg.batchSize, func(batch []models.SwiftCode) error {
+			result, err := load(loadCtx, batch)
+			if err != nil {
+				return err
+			}
+			total.Inserted += result.Inserted
+			total.Updated += result.Updated
+			total.Unchanged += result.Unchanged
+			log.Printf("✅ Loaded batch of %d SWIFT codes (%d inserted, %d updated, %d unchanged)",
+				len(batch), result.Inserted, result.Updated, result.Unchanged)
+			return nil
+		})
+		if err != nil {
+			if tx != nil {
+				tx.Rollback()
+			}
+			log.Fatalf("⚠️ Failed to load SWIFT codes from %s: %v", input, err)
+		}
+		if !cfg.strict {
+			log.Printf("📄 %s", input)
+			printReport(report)
+		}
+	}
+	if tx != nil {
+		if err := tx.Commit(); err != nil {
+			log.Fatalf("⚠️ Failed to commit load: %v", err)
+		}
 	}
-	log.Printf("✅ Inserted %d SWIFT codes in %v", len(codes), time.Since(start))
+	log.Printf("✅ Loaded SWIFT codes in %v: %d inserted, %d updated, %d unchanged",
+		time.Since(start), total.Inserted, total.Updated, total.Unchanged)
 
 	log.Println("🎉 Database initialization complete!")
 }
+
+// currentUser names the operator recorded in the change history
+func currentUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
+
+// printReport logs the parse summary followed by every skipped and rejected row
+func printReport(report *parser.Report) {
+	log.Printf("📋 Parse report: %s", report.Summary())
+	for _, issue := range report.Skipped {
+		log.Printf("   skipped  %s", issue)
+	}
+	for _, issue := range report.Rejected {
+		log.Printf("   rejected %s", issue)
+	}
+}
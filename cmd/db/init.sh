@@ -1,11 +1,11 @@
 #!/bin/sh
 set -e
 
-echo "Running schema.sql to initialize the database schema..."
-psql -U "$POSTGRES_USER" -d "$POSTGRES_DB" -f /docker-entrypoint-initdb.d/01-schema.sql
+echo "Running schema migrations..."
+cd /app
+go run ./cmd/db migrate up
 
 echo "Running Go parser to populate the database with data..."
-cd /app
-go run cmd/db/init.go
+go run ./cmd/db
 
 echo "Database initialization complete!"
\ No newline at end of file
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"swift-parser/internal/database"
+)
+
+// runMigrate implements the migrate subcommand:
+//
+//	migrate [up]            apply all pending migrations
+//	migrate down [-steps n] revert the n newest migrations
+//	migrate version         print the current and latest schema versions
+func runMigrate(args []string) {
+	action := "up"
+	if len(args) > 0 && args[0] != "" && args[0][0] != '-' {
+		action, args = args[0], args[1:]
+	}
+
+	flags := flag.NewFlagSet("migrate", flag.ExitOnError)
+	dsn := flags.String("dsn", "", dsnUsage)
+	steps := flags.Int("steps", 1, "number of migrations to revert with down")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s migrate [up|down|version] [flags]\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	db, err := database.NewDB(connString(*dsn))
+	if err != nil {
+		log.Fatalf("⚠️ Database connection failed: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	switch action {
+	case "up":
+		applied, err := db.MigrateUp(ctx)
+		if err != nil {
+			log.Fatalf("⚠️ Migration failed: %v", err)
+		}
+		for _, m := range applied {
+			log.Printf("✅ Applied migration %d_%s", m.Version, m.Name)
+		}
+		if len(applied) == 0 {
+			log.Println("✅ Schema is up to date")
+		}
+
+	case "down":
+		if *steps <= 0 {
+			log.Fatalf("⚠️ -steps must be positive, got %d", *steps)
+		}
+		reverted, err := db.MigrateDown(ctx, *steps)
+		if err != nil {
+			log.Fatalf("⚠️ Migration failed: %v", err)
+		}
+		for _, m := range reverted {
+			log.Printf("✅ Reverted migration %d_%s", m.Version, m.Name)
+		}
+
+	case "version":
+		current, err := db.SchemaVersion(ctx)
+		if err != nil {
+			log.Fatalf("⚠️ Failed to read schema version: %v", err)
+		}
+		latest, err := database.LatestSchemaVersion()
+		if err != nil {
+			log.Fatalf("⚠️ Failed to read migrations: %v", err)
+		}
+		fmt.Printf("current: %d\nlatest:  %d\n", current, latest)
+
+	default:
+		flags.Usage()
+		os.Exit(2)
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"swift-parser/internal/database"
+	"time"
+)
+
+// defaultPurgeAge matches the API server's default restore window
+const defaultPurgeAge = 30 * 24 * time.Hour
+
+// runPurge implements the purge subcommand, which permanently removes
+// SWIFT codes soft-deleted longer ago than -older-than
+func runPurge(args []string) {
+	flags := flag.NewFlagSet("purge", flag.ExitOnError)
+	dsn := flags.String("dsn", "", dsnUsage)
+	olderThan := flags.Duration("older-than", defaultPurgeAge, "purge codes deleted longer ago than this")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s purge [flags]\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if *olderThan < 0 {
+		log.Fatalf("⚠️ -older-than must not be negative, got %s", *olderThan)
+	}
+
+	db, err := database.NewDB(connString(*dsn))
+	if err != nil {
+		log.Fatalf("⚠️ Database connection failed: %v", err)
+	}
+	defer db.Close()
+
+	ctx := database.WithAudit(context.Background(), database.Audit{Actor: currentUser(), Source: database.SourceCLI})
+	purged, err := db.PurgeDeletedSWIFTCodes(ctx, *olderThan)
+	if err != nil {
+		log.Fatalf("⚠️ Purge failed: %v", err)
+	}
+	log.Printf("✅ Purged %d SWIFT codes deleted more than %s ago", purged, *olderThan)
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"path/filepath"
+	"strings"
+	"swift-parser/internal/database"
+	"swift-parser/internal/models"
+	"swift-parser/internal/parser"
+	"time"
+)
+
+// syncInputs makes the database match the input files within the
+// configured countries, deleting codes missing from them, in a single
+// transaction
+func syncInputs(ctx context.Context, db *database.DB, cfg config, opts parser.Options, actor string) {
+	var codes []models.SwiftCode
+	var rejected []string
+	names := make([]string, len(cfg.inputs))
+	for i, input := range cfg.inputs {
+		parsed, report, err := parser.ParseFile(input, opts)
+		if err != nil {
+			log.Fatalf("⚠️ Failed to parse %s: %v", input, err)
+		}
+		if !cfg.strict {
+			log.Printf("📄 %s", input)
+			printReport(report)
+		}
+		codes = append(codes, parsed...)
+		rejected = append(rejected, report.RejectedCodes()...)
+		names[i] = filepath.Base(input)
+	}
+
+	syncCtx := database.WithAudit(ctx, database.Audit{
+		Actor:  actor,
+		Source: database.ImportSource(strings.Join(names, ",")),
+	})
+	syncOpts := database.SyncOptions{
+		Countries:         cfg.syncCountries,
+		MaxRemovedPercent: cfg.maxRemovedPercent,
+		Keep:              rejected,
+	}
+
+	start := time.Now()
+	result, err := db.SyncSWIFTCodes(syncCtx, codes, syncOpts)
+	if errors.Is(err, database.ErrSyncThreshold) {
+		log.Fatalf("⚠️ Refusing to sync: %v (raise -max-removed-percent to allow it)", err)
+	}
+	if err != nil {
+		log.Fatalf("⚠️ Failed to sync SWIFT codes: %v", err)
+	}
+
+	for _, code := range result.Removed {
+		log.Printf("   removed  %s", code)
+	}
+	scope := "all countries"
+	if len(cfg.syncCountries) > 0 {
+		scope = strings.Join(cfg.syncCountries, ", ")
+	}
+	log.Printf("✅ Synced SWIFT codes for %s in %v: %d upserted, %d removed, %d out of scope",
+		scope, time.Since(start), result.Upserted, len(result.Removed), result.OutOfScope)
+}
@@ -1,11 +1,16 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
+	"strconv"
 	"swift-parser/internal/api"
 	"swift-parser/internal/database"
+	"swift-parser/internal/importer"
+	"swift-parser/internal/parser"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -18,36 +23,160 @@ func main() {
 		log.Println("⚠️ No .env file found, using environment variables")
 	}
 
-	// Database connection
-	connStr := fmt.Sprintf(
-		"host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-		os.Getenv("DB_HOST"),
-		os.Getenv("DB_PORT"),
-		os.Getenv("DB_USER"),
-		os.Getenv("DB_PASSWORD"),
-		os.Getenv("DB_NAME"),
-	)
+	var store database.SwiftCodeStore
+	var jobs database.ImportJobStore
+	if os.Getenv("STORAGE") == "memory" {
+		memStore, err := newMemoryStore(os.Getenv("SEED_FILE"))
+		if err != nil {
+			log.Fatalf("⚠️ In-memory store setup failed: %v", err)
+		}
+		store, jobs = memStore, memStore
+		log.Println("✅ Using in-memory store")
+	} else {
+		// Database connection
+		connStr := fmt.Sprintf(
+			"host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
+			os.Getenv("DB_HOST"),
+			os.Getenv("DB_PORT"),
+			os.Getenv("DB_USER"),
+			os.Getenv("DB_PASSWORD"),
+			os.Getenv("DB_NAME"),
+		)
+
+		db, err := database.NewDB(connStr)
+		if err != nil {
+			log.Fatalf("⚠️ Database connection failed: %v", err)
+		}
+		defer db.Close()
+		log.Println("✅ Connected to database")
+
+		if err := db.CheckSchemaVersion(context.Background()); err != nil {
+			log.Fatalf("⚠️ Refusing to start: %v (run `go run ./cmd/db migrate`)", err)
+		}
+		store, jobs = db, db
+	}
 
-	db, err := database.NewDB(connStr)
+	retention, err := envDuration("DELETE_RETENTION", api.DefaultRetention)
 	if err != nil {
-		log.Fatalf("⚠️ Database connection failed: %v", err)
+		log.Fatalf("⚠️ %v", err)
+	}
+	purgeInterval, err := envDuration("PURGE_INTERVAL", time.Hour)
+	if err != nil {
+		log.Fatalf("⚠️ %v", err)
+	}
+	if purgeInterval > 0 {
+		go purgeDeleted(store, retention, purgeInterval)
+	}
+
+	workers := importer.DefaultWorkers
+	if raw := os.Getenv("IMPORT_WORKERS"); raw != "" {
+		if workers, err = strconv.Atoi(raw); err != nil || workers <= 0 {
+			log.Fatalf("⚠️ Invalid IMPORT_WORKERS %q: must be a positive integer", raw)
+		}
+	}
+	maxUploadMB := api.DefaultMaxUploadSize >> 20
+	if raw := os.Getenv("MAX_UPLOAD_MB"); raw != "" {
+		if maxUploadMB, err = strconv.Atoi(raw); err != nil || maxUploadMB <= 0 {
+			log.Fatalf("⚠️ Invalid MAX_UPLOAD_MB %q: must be a positive integer", raw)
+		}
+	}
+	runner := importer.NewRunner(store, jobs, workers)
+	go func() {
+		if err := runner.Run(context.Background()); err != nil {
+			log.Printf("⚠️ Import jobs are not processed: %v", err)
+		}
+	}()
+
+	adminToken := os.Getenv("ADMIN_TOKEN")
+	if adminToken == "" {
+		log.Println("⚠️ ADMIN_TOKEN is not set, admin endpoints are disabled")
 	}
-	defer db.Close()
-	log.Println("✅ Connected to database")
 
 	// Setup and start API server
-	router := api.NewRouter(db)
+	router := api.NewRouter(store,
+		api.WithAdminToken(adminToken),
+		api.WithRetention(retention),
+		api.WithImporter(runner),
+		api.WithMaxUploadSize(int64(maxUploadMB)<<20),
+	)
 	engine := router.Setup()
 
 	fmt.Println("\n🚀 API Server Ready!")
 	fmt.Println("\nAvailable endpoints:")
-	fmt.Println("1. GET    http://localhost:8080/v1/swift-codes/{swift-code}")
-	fmt.Println("2. GET    http://localhost:8080/v1/swift-codes/country/{countryISO2}")
-	fmt.Println("3. POST   http://localhost:8080/v1/swift-codes")
-	fmt.Println("4. DELETE http://localhost:8080/v1/swift-codes/{swift-code}")
+	fmt.Println(" 1. GET    http://localhost:8080/v1/swift-codes/{swift-code}")
+	fmt.Println(" 2. GET    http://localhost:8080/v1/swift-codes/{prefix} (institution, bank and country, or BIC8)")
+	fmt.Println(" 3. GET    http://localhost:8080/v1/swift-codes/{swift-code}/history")
+	fmt.Println(" 4. GET    http://localhost:8080/v1/swift-codes/country/{countryISO2}")
+	fmt.Println(" 5. GET    http://localhost:8080/v1/swift-codes/search?q={text}")
+	fmt.Println(" 6. GET    http://localhost:8080/v1/swift-codes/export?format={csv|xlsx|ndjson}")
+	fmt.Println(" 7. POST   http://localhost:8080/v1/swift-codes")
+	fmt.Println(" 8. PUT    http://localhost:8080/v1/swift-codes/{swift-code}")
+	fmt.Println(" 9. PATCH  http://localhost:8080/v1/swift-codes/{swift-code}")
+	fmt.Println("10. DELETE http://localhost:8080/v1/swift-codes/{swift-code}")
+	fmt.Println("11. POST   http://localhost:8080/v1/swift-codes/{swift-code}/restore (admin)")
+	fmt.Println("12. POST   http://localhost:8080/v1/swift-codes/import (admin)")
+	fmt.Println("13. POST   http://localhost:8080/v1/imports (admin)")
+	fmt.Println("14. GET    http://localhost:8080/v1/imports/{id} (admin)")
+	fmt.Println("15. DELETE http://localhost:8080/v1/imports/{id} (admin)")
+	fmt.Println("16. GET    http://localhost:8080/v1/admin/swift-codes/deleted (admin)")
+	fmt.Println("\nSWIFT code and country lookups accept ?asOf={RFC 3339 time}")
 
 	log.Printf("📡 Starting server on :8080...")
 	if err := engine.Run(":8080"); err != nil {
 		log.Fatalf("⚠️ Server failed to start: %v", err)
 	}
 }
+
+// newMemoryStore creates an in-memory store, optionally seeded from an Excel file
+func newMemoryStore(seedFile string) (*database.MemoryStore, error) {
+	store := database.NewMemoryStore()
+	if seedFile == "" {
+		return store, nil
+	}
+
+	codes, err := parser.ParseExcelFile(seedFile)
+	if err != nil {
+		return nil, err
+	}
+	if err := store.InsertSwiftCodes(context.Background(), codes); err != nil {
+		return nil, err
+	}
+	log.Printf("✅ Seeded %d SWIFT codes from %s", len(codes), seedFile)
+	return store, nil
+}
+
+// envDuration reads a duration such as "720h" from the environment
+// variable name, returning def when it is unset
+func envDuration(name string, def time.Duration) (time.Duration, error) {
+	raw := os.Getenv(name)
+	if raw == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid %s %q: must be a non-negative duration such as 720h", name, raw)
+	}
+	return d, nil
+}
+
+// purgeDeleted permanently removes SWIFT codes deleted longer ago than
+// retention, every interval. A zero retention keeps them forever.
+func purgeDeleted(store database.SwiftCodeStore, retention, interval time.Duration) {
+	if retention == 0 {
+		return
+	}
+
+	ctx := database.WithAudit(context.Background(), database.Audit{Actor: "purge", Source: database.SourceScheduler})
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		purged, err := store.PurgeDeletedSWIFTCodes(ctx, retention)
+		if err != nil {
+			log.Printf("⚠️ Purging deleted SWIFT codes failed: %v", err)
+			continue
+		}
+		if purged > 0 {
+			log.Printf("🧹 Purged %d SWIFT codes deleted more than %s ago", purged, retention)
+		}
+	}
+}
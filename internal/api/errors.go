@@ -0,0 +1,62 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"swift-parser/internal/database"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrorResponse is the JSON body returned by every endpoint on failure
+type ErrorResponse struct {
+	Error  string       `json:"error"`
+	Fields []FieldError `json:"fields,omitempty"`
+	// Branches lists the branches that prevented deleting a headquarter
+	Branches []string `json:"branches,omitempty"`
+}
+
+// statusFor maps a store error to its HTTP status code
+func statusFor(err error) int {
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.Is(err, database.ErrInvalidInput):
+		return http.StatusBadRequest
+	case errors.Is(err, database.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, database.ErrAlreadyExists), errors.Is(err, database.ErrHasBranches),
+		errors.Is(err, database.ErrJobFinished), errors.Is(err, database.ErrSyncThreshold):
+		return http.StatusConflict
+	case errors.Is(err, database.ErrVersionMismatch):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, database.ErrRetentionExpired):
+		return http.StatusGone
+	case errors.As(err, &tooLarge):
+		return http.StatusRequestEntityTooLarge
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// respondError writes err as an ErrorResponse with the matching status code.
+// Internal errors are logged and replaced by a generic message.
+func respondError(c *gin.Context, err error) {
+	status := statusFor(err)
+	response := ErrorResponse{Error: err.Error()}
+	if status == http.StatusInternalServerError {
+		log.Printf("Internal error on %s %s: %v", c.Request.Method, c.Request.URL.Path, err)
+		response.Error = "Internal server error"
+	}
+
+	var verr *ValidationError
+	if errors.As(err, &verr) {
+		response.Error = "validation failed"
+		response.Fields = verr.Fields
+	}
+	var berr *database.BranchesExistError
+	if errors.As(err, &berr) {
+		response.Branches = berr.Branches
+	}
+	c.AbortWithStatusJSON(status, response)
+}
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+	"swift-parser/internal/database"
+	"swift-parser/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// etagFor returns the entity tag of a GET response for code. The tag starts
+// with the record version, which is what If-Match is checked against. A
+// headquarter response also lists its branches, so their codes and
+// versions are folded into a suffix that only affects If-None-Match.
+func etagFor(code *models.SwiftCode, branches []models.SwiftCode) string {
+	if !code.IsHeadquarter {
+		return versionETag(code.Version)
+	}
+
+	h := fnv.New64a()
+	for _, branch := range branches {
+		fmt.Fprintf(h, "%s:%d;", branch.SwiftCode, branch.Version)
+	}
+	return fmt.Sprintf(`"%d-%x"`, code.Version, h.Sum64())
+}
+
+// setWriteETag sets the ETag of a write response to the tag a GET of code
+// returns, so a client can send it back in If-None-Match. The write has
+// already succeeded, so the header is left out if the branches of a
+// headquarter cannot be read.
+func (r *Router) setWriteETag(c *gin.Context, code *models.SwiftCode) {
+	var branches []models.SwiftCode
+	if code.IsHeadquarter {
+		var err error
+		if branches, err = r.store.GetBranches(code.SwiftCode); err != nil {
+			return
+		}
+	}
+	c.Header("ETag", etagFor(code, branches))
+}
+
+func versionETag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// notModified reports whether the If-None-Match header lists etag. Weak
+// comparison is used, as RFC 9110 requires for If-None-Match.
+func notModified(c *gin.Context, etag string) bool {
+	header := c.GetHeader("If-None-Match")
+	if header == "" {
+		return false
+	}
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// ifMatchVersion returns the record version required by the If-Match
+// header, or 0 when the header is absent or "*". A tag that cannot match
+// any version yields database.ErrVersionMismatch, and several tags are
+// rejected as invalid input.
+func ifMatchVersion(c *gin.Context) (int64, error) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" || header == "*" {
+		return 0, nil
+	}
+	if strings.Contains(header, ",") {
+		verr := &ValidationError{}
+		verr.add("If-Match", "must be a single entity tag")
+		return 0, verr
+	}
+
+	tag, ok := strings.CutPrefix(header, `"`)
+	if ok {
+		tag, ok = strings.CutSuffix(tag, `"`)
+	}
+	// Writes only depend on the record, not on the branch suffix
+	tag, _, _ = strings.Cut(tag, "-")
+	version, err := strconv.ParseInt(tag, 10, 64)
+	if !ok || err != nil || version < 1 {
+		return 0, fmt.Errorf("If-Match %s: %w", header, database.ErrVersionMismatch)
+	}
+	return version, nil
+}
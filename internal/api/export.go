@@ -0,0 +1,224 @@
+package api
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"swift-parser/internal/database"
+	"swift-parser/internal/models"
+	"swift-parser/internal/parser"
+	"swift-parser/pkg/validator"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xuri/excelize/v2"
+)
+
+// Export formats accepted by the format query parameter
+const (
+	FormatCSV    = "csv"
+	FormatXLSX   = "xlsx"
+	FormatNDJSON = "ndjson"
+)
+
+// exportSheet names the worksheet of an XLSX export
+const exportSheet = "Sheet1"
+
+var exportContentTypes = map[string]string{
+	FormatCSV:    "text/csv; charset=utf-8",
+	FormatXLSX:   "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	FormatNDJSON: "application/x-ndjson",
+}
+
+// exporter writes codes to a response one at a time
+type exporter interface {
+	Write(code models.SwiftCode) error
+	// Close writes whatever the format needs after the last code
+	Close() error
+	// Discard releases an export that failed before Close
+	Discard()
+}
+
+// newExporter returns an exporter writing format to w. CSV and XLSX start
+// with the header row parser.Header, so exports can be imported again.
+func newExporter(format string, w io.Writer) (exporter, error) {
+	switch format {
+	case FormatXLSX:
+		e, err := newXLSXExporter(w)
+		if err != nil {
+			return nil, err
+		}
+		return e, nil
+	case FormatNDJSON:
+		return &ndjsonExporter{enc: json.NewEncoder(w)}, nil
+	default:
+		e := &csvExporter{w: csv.NewWriter(w)}
+		return e, e.w.Write(parser.Header())
+	}
+}
+
+type csvExporter struct {
+	w *csv.Writer
+}
+
+func (e *csvExporter) Write(code models.SwiftCode) error {
+	return e.w.Write(parser.Record(code))
+}
+
+func (e *csvExporter) Close() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func (e *csvExporter) Discard() {}
+
+type ndjsonExporter struct {
+	enc *json.Encoder
+}
+
+func (e *ndjsonExporter) Write(code models.SwiftCode) error {
+	return e.enc.Encode(valuesOf(&code))
+}
+
+func (e *ndjsonExporter) Close() error { return nil }
+func (e *ndjsonExporter) Discard()     {}
+
+// xlsxExporter writes rows with an excelize stream writer, which spills
+// them to a temporary file beyond a few megabytes. The workbook is a zip
+// archive, so it can only be sent once the last row is written.
+type xlsxExporter struct {
+	w      io.Writer
+	file   *excelize.File
+	stream *excelize.StreamWriter
+	row    int
+}
+
+func newXLSXExporter(w io.Writer) (*xlsxExporter, error) {
+	file := excelize.NewFile()
+	stream, err := file.NewStreamWriter(exportSheet)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	e := &xlsxExporter{w: w, file: file, stream: stream}
+	if err := e.writeRow(parser.Header()); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return e, nil
+}
+
+func (e *xlsxExporter) writeRow(values []string) error {
+	e.row++
+	cell, err := excelize.CoordinatesToCellName(1, e.row)
+	if err != nil {
+		return err
+	}
+	cells := make([]interface{}, len(values))
+	for i, v := range values {
+		cells[i] = v
+	}
+	return e.stream.SetRow(cell, cells)
+}
+
+func (e *xlsxExporter) Write(code models.SwiftCode) error {
+	return e.writeRow(parser.Record(code))
+}
+
+func (e *xlsxExporter) Close() error {
+	defer e.file.Close()
+	if err := e.stream.Flush(); err != nil {
+		return err
+	}
+	return e.file.Write(e.w)
+}
+
+func (e *xlsxExporter) Discard() { e.file.Close() }
+
+// parseExportQuery reads the format, country and isHeadquarter query
+// parameters
+func parseExportQuery(c *gin.Context) (string, database.ExportQuery, error) {
+	verr := &ValidationError{}
+	var q database.ExportQuery
+
+	format := strings.ToLower(c.DefaultQuery("format", FormatCSV))
+	if _, ok := exportContentTypes[format]; !ok {
+		verr.add("format", "must be %s, %s or %s", FormatCSV, FormatXLSX, FormatNDJSON)
+	}
+
+	for _, raw := range c.QueryArray("country") {
+		for _, country := range strings.Split(raw, ",") {
+			country = strings.ToUpper(strings.TrimSpace(country))
+			if !validator.IsKnownCountry(country) {
+				verr.add("country", "%q is not an ISO 3166-1 country code", country)
+				continue
+			}
+			q.Countries = append(q.Countries, country)
+		}
+	}
+
+	if raw := c.Query("isHeadquarter"); raw != "" {
+		isHeadquarter, err := strconv.ParseBool(raw)
+		if err != nil {
+			verr.add("isHeadquarter", "must be true or false")
+		} else {
+			q.IsHeadquarter = &isHeadquarter
+		}
+	}
+
+	if len(verr.Fields) > 0 {
+		return "", q, verr
+	}
+	return format, q, nil
+}
+
+// ExportSWIFTCodes streams every code matching the country and
+// isHeadquarter filters as CSV, XLSX or NDJSON, ordered by code. Rows are
+// written as they are read from the store. Errors after the first row can
+// no longer change the status, so they cut the download short instead.
+func (r *Router) ExportSWIFTCodes(c *gin.Context) {
+	format, q, err := parseExportQuery(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	var out exporter
+	start := func() (err error) {
+		c.Header("Content-Type", exportContentTypes[format])
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="swift-codes.%s"`, format))
+		out, err = newExporter(format, c.Writer)
+		return err
+	}
+
+	err = r.store.ExportSWIFTCodes(c.Request.Context(), q, func(code models.SwiftCode) error {
+		if out == nil {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+		return out.Write(code)
+	})
+	if err == nil && out == nil {
+		err = start()
+	}
+	if err == nil {
+		if err = out.Close(); err == nil {
+			return
+		}
+	} else if out != nil {
+		out.Discard()
+	}
+
+	if !c.Writer.Written() {
+		c.Header("Content-Disposition", "")
+		c.Header("Content-Type", "")
+		respondError(c, err)
+		return
+	}
+	log.Printf("Export aborted on %s %s: %v", c.Request.Method, c.Request.URL.Path, err)
+	c.Abort()
+}
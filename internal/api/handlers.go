@@ -4,7 +4,10 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"swift-parser/internal/database"
 	"swift-parser/internal/models"
+	"swift-parser/pkg/validator"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -28,34 +31,99 @@ type HeadquarterResponse struct {
 	Branches      []BranchResponse `json:"branches"`
 }
 
+// PrefixResponse groups the codes matching a 4 or 6 character prefix under
+// their headquarters. Branches whose headquarter is not registered are
+// listed separately.
+type PrefixResponse struct {
+	Prefix       string                `json:"prefix"`
+	Headquarters []HeadquarterResponse `json:"headquarters"`
+	Branches     []BranchResponse      `json:"branches"`
+}
+
 type CountryResponse struct {
 	CountryISO2 string           `json:"countryISO2"`
 	CountryName string           `json:"countryName"`
 	SwiftCodes  []BranchResponse `json:"swiftCodes"`
+	NextCursor  string           `json:"nextCursor,omitempty"`
 }
 
+// GetSWIFTCode looks up a full 11 character code, or a BIC8 as its XXX
+// headquarter. 4 character institution codes and 6 character bank and
+// country prefixes return every matching code grouped by headquarter. With
+// asOf, the code and its branches are returned as they were at that time.
 func (r *Router) GetSWIFTCode(c *gin.Context) {
-	swiftCode := c.Param("swiftCode")
+	swiftCode := pathSwiftCode(c)
+
+	verr := &ValidationError{}
+	asOf := parseAsOf(c, verr)
+	if !asOf.IsZero() && (len(swiftCode) == 4 || len(swiftCode) == 6) {
+		verr.add("asOf", "is only supported for 8 and 11 character codes")
+	}
+	if len(verr.Fields) > 0 {
+		respondError(c, verr)
+		return
+	}
+
+	if len(swiftCode) == 4 || len(swiftCode) == 6 {
+		r.getSWIFTCodesByPrefix(c, swiftCode)
+		return
+	}
+
+	if !asOf.IsZero() {
+		r.getSWIFTCodeAsOf(c, swiftCode, asOf)
+		return
+	}
 
-	code, err := r.db.GetSWIFTCode(swiftCode)
+	code, err := r.store.GetSWIFTCode(swiftCode)
 	if err != nil {
-		if err.Error() == "swift code not found" {
-			c.JSON(http.StatusNotFound, gin.H{
-				"error": fmt.Sprintf("SWIFT code '%s' not found in database", swiftCode),
-			})
+		respondError(c, err)
+		return
+	}
+
+	var branches []models.SwiftCode
+	if code.IsHeadquarter {
+		branches, err = r.store.GetBranches(swiftCode)
+		if err != nil {
+			respondError(c, err)
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+	}
+
+	etag := etagFor(code, branches)
+	c.Header("ETag", etag)
+	if notModified(c, etag) {
+		c.Status(http.StatusNotModified)
 		return
 	}
 
+	writeSWIFTCode(c, code, branches)
+}
+
+// getSWIFTCodeAsOf responds with a code and its branches reconstructed from
+// the change history. Historic records carry no version, so no ETag is set.
+func (r *Router) getSWIFTCodeAsOf(c *gin.Context, swiftCode string, asOf time.Time) {
+	code, err := r.store.GetSWIFTCodeAsOf(swiftCode, asOf)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	var branches []models.SwiftCode
 	if code.IsHeadquarter {
-		branches, err := r.db.GetBranches(swiftCode)
+		branches, err = r.store.GetBranchesAsOf(swiftCode, asOf)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get branches"})
+			respondError(c, err)
 			return
 		}
+	}
+
+	writeSWIFTCode(c, code, branches)
+}
 
+// writeSWIFTCode responds with a headquarter and its branches, or with a
+// branch on its own
+func writeSWIFTCode(c *gin.Context, code *models.SwiftCode, branches []models.SwiftCode) {
+	if code.IsHeadquarter {
 		branchResponses := make([]BranchResponse, len(branches))
 		for i, branch := range branches {
 			branchResponses[i] = BranchResponse{
@@ -83,28 +151,101 @@ func (r *Router) GetSWIFTCode(c *gin.Context) {
 	c.JSON(http.StatusOK, code)
 }
 
+func (r *Router) getSWIFTCodesByPrefix(c *gin.Context, prefix string) {
+	codes, err := r.store.GetSWIFTCodesByPrefix(prefix)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	headquarters, branches := groupByHeadquarter(codes)
+	c.JSON(http.StatusOK, PrefixResponse{
+		Prefix:       prefix,
+		Headquarters: headquarters,
+		Branches:     branches,
+	})
+}
+
+// groupByHeadquarter assigns each branch in codes, which must be ordered by
+// code, to a headquarter sharing its first 6 characters as GetBranches does,
+// preferring the one with the same BIC8. Branches without a headquarter in
+// codes are returned separately.
+func groupByHeadquarter(codes []models.SwiftCode) ([]HeadquarterResponse, []BranchResponse) {
+	headquarters := []HeadquarterResponse{}
+	index := make(map[string]int)
+	byBank := make(map[string]int)
+	for _, code := range codes {
+		if !code.IsHeadquarter {
+			continue
+		}
+		index[code.SwiftCode[:8]] = len(headquarters)
+		if _, ok := byBank[code.SwiftCode[:6]]; !ok {
+			byBank[code.SwiftCode[:6]] = len(headquarters)
+		}
+		headquarters = append(headquarters, HeadquarterResponse{
+			Address:       code.Address,
+			BankName:      code.BankName,
+			CountryISO2:   code.CountryISO2,
+			CountryName:   code.CountryName,
+			IsHeadquarter: true,
+			SwiftCode:     code.SwiftCode,
+			Branches:      []BranchResponse{},
+		})
+	}
+
+	orphans := []BranchResponse{}
+	for _, code := range codes {
+		if code.IsHeadquarter {
+			continue
+		}
+		branch := BranchResponse{
+			Address:       code.Address,
+			BankName:      code.BankName,
+			CountryISO2:   code.CountryISO2,
+			IsHeadquarter: false,
+			SwiftCode:     code.SwiftCode,
+		}
+
+		i, ok := index[code.SwiftCode[:8]]
+		if !ok {
+			i, ok = byBank[code.SwiftCode[:6]]
+		}
+		if !ok {
+			orphans = append(orphans, branch)
+			continue
+		}
+		headquarters[i].Branches = append(headquarters[i].Branches, branch)
+	}
+	return headquarters, orphans
+}
+
 func (r *Router) GetSWIFTCodesByCountry(c *gin.Context) {
 	countryCode := strings.ToUpper(c.Param("countryISO2"))
 
 	// Validate country code format
 	if len(countryCode) != 2 {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid country code format. Must be 2 letters (ISO-2)",
-		})
+		respondError(c, fmt.Errorf("country code must be 2 letters (ISO-2): %w", database.ErrInvalidInput))
 		return
 	}
 
-	codes, err := r.db.GetSWIFTCodesByCountry(countryCode)
+	q, limit, err := parseCountryQuery(c)
 	if err != nil {
-		if err.Error() == "no swift codes found for this country" {
-			c.JSON(http.StatusNotFound, gin.H{
-				"error": fmt.Sprintf("No SWIFT codes found for country '%s'", countryCode),
-			})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		respondError(c, err)
 		return
 	}
+
+	codes, err := r.store.GetSWIFTCodesByCountry(countryCode, q)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	var nextCursor string
+	if limit > 0 && len(codes) > limit {
+		codes = codes[:limit]
+		nextCursor = encodeCursor(q.SortBy, database.KeysetOf(codes[limit-1]))
+	}
+
 	swiftCodes := make([]BranchResponse, len(codes))
 	for i, code := range codes {
 		swiftCodes[i] = BranchResponse{
@@ -116,10 +257,16 @@ func (r *Router) GetSWIFTCodesByCountry(c *gin.Context) {
 		}
 	}
 
+	countryName, _ := validator.CountryName(countryCode)
+	if len(codes) > 0 {
+		countryName = codes[0].CountryName
+	}
+
 	response := CountryResponse{
 		CountryISO2: countryCode,
-		CountryName: codes[0].CountryName,
+		CountryName: countryName,
 		SwiftCodes:  swiftCodes,
+		NextCursor:  nextCursor,
 	}
 	c.JSON(http.StatusOK, response)
 }
@@ -127,23 +274,148 @@ func (r *Router) GetSWIFTCodesByCountry(c *gin.Context) {
 func (r *Router) PostSWIFTCode(c *gin.Context) {
 	var newCode models.SwiftCode
 	if err := c.ShouldBindJSON(&newCode); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		respondError(c, fmt.Errorf("malformed request body: %w", database.ErrInvalidInput))
+		return
+	}
+
+	normalizeSwiftCode(&newCode)
+	if err := validateSwiftCode(&newCode); err != nil {
+		respondError(c, err)
 		return
 	}
 
-	if err := r.db.AddSWIFTCode(&newCode); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add SWIFT code"})
+	if err := r.store.AddSWIFTCode(auditContext(c), &newCode); err != nil {
+		respondError(c, err)
 		return
 	}
 
+	r.setWriteETag(c, &newCode)
 	c.JSON(http.StatusCreated, gin.H{"message": "SWIFT code added successfully"})
 }
 
+// PutSWIFTCode replaces every field of an existing SWIFT code, so every
+// field must be present; an empty address is given as "". The code itself
+// cannot change; a swiftCode in the body must match the path. An If-Match
+// header makes the update conditional on the current ETag.
+func (r *Router) PutSWIFTCode(c *gin.Context) {
+	ifVersion, err := ifMatchVersion(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	var body swiftCodePatch
+	if err := c.ShouldBindJSON(&body); err != nil {
+		respondError(c, fmt.Errorf("malformed request body: %w", database.ErrInvalidInput))
+		return
+	}
+	verr := &ValidationError{}
+	if body.requireAll(verr); len(verr.Fields) > 0 {
+		respondError(c, verr)
+		return
+	}
+
+	var code models.SwiftCode
+	body.apply(&code)
+	r.updateSWIFTCode(c, &code, database.UpdateOptions{IfVersion: ifVersion})
+}
+
+// PatchSWIFTCode updates the fields present in the request body, keeping
+// the stored value of the others. The update is always conditional on the
+// version that was read, so concurrent writes are never silently merged.
+func (r *Router) PatchSWIFTCode(c *gin.Context) {
+	ifVersion, err := ifMatchVersion(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	var patch swiftCodePatch
+	if err := c.ShouldBindJSON(&patch); err != nil {
+		respondError(c, fmt.Errorf("malformed request body: %w", database.ErrInvalidInput))
+		return
+	}
+
+	existing, err := r.store.GetSWIFTCode(pathSwiftCode(c))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	if ifVersion != 0 && ifVersion != existing.Version {
+		respondError(c, fmt.Errorf("swift code %q: %w", existing.SwiftCode, database.ErrVersionMismatch))
+		return
+	}
+
+	patch.apply(existing)
+	r.updateSWIFTCode(c, existing, database.UpdateOptions{IfVersion: existing.Version})
+}
+
+// updateSWIFTCode validates code against the path and stores it
+func (r *Router) updateSWIFTCode(c *gin.Context, code *models.SwiftCode, opts database.UpdateOptions) {
+	swiftCode := pathSwiftCode(c)
+	if code.SwiftCode == "" {
+		code.SwiftCode = swiftCode
+	}
+
+	normalizeSwiftCode(code)
+	if code.SwiftCode != swiftCode {
+		verr := &ValidationError{}
+		verr.add("swiftCode", "must match the code in the path (%s)", swiftCode)
+		respondError(c, verr)
+		return
+	}
+	if err := validateSwiftCode(code); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	if err := r.store.UpdateSWIFTCode(auditContext(c), code, opts); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	r.setWriteETag(c, code)
+	c.JSON(http.StatusOK, gin.H{"message": "SWIFT code updated successfully"})
+}
+
+// pathSwiftCode returns the swiftCode path parameter normalized like a
+// request body code
+func pathSwiftCode(c *gin.Context) string {
+	code := models.SwiftCode{SwiftCode: c.Param("swiftCode")}
+	normalizeSwiftCode(&code)
+	return code.SwiftCode
+}
+
+// DeleteSWIFTCode soft-deletes a SWIFT code, which admins can restore
+// within the retention window. An If-Match header makes the delete
+// conditional on the current ETag. The branches query parameter decides
+// what happens to the branches of a headquarter: reject (the default)
+// fails with 409 listing them, cascade deletes them too and detach leaves
+// them in place.
 func (r *Router) DeleteSWIFTCode(c *gin.Context) {
-	swiftCode := c.Param("swiftCode")
+	swiftCode := pathSwiftCode(c)
+
+	ifVersion, err := ifMatchVersion(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	opts := database.DeleteOptions{IfVersion: ifVersion, Branches: database.BranchesReject}
+	if raw := c.Query("branches"); raw != "" {
+		opts.Branches = database.BranchPolicy(raw)
+		switch opts.Branches {
+		case database.BranchesReject, database.BranchesCascade, database.BranchesDetach:
+		default:
+			verr := &ValidationError{}
+			verr.add("branches", "must be reject, cascade or detach")
+			respondError(c, verr)
+			return
+		}
+	}
 
-	if err := r.db.DeleteSWIFTCode(swiftCode); err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "SWIFT code not found"})
+	if err := r.store.DeleteSWIFTCode(auditContext(c), swiftCode, opts); err != nil {
+		respondError(c, err)
 		return
 	}
 
@@ -1,12 +1,23 @@
 package api
 
 import (
+	"bytes"
+	"context"
+	"encoding/csv"
 	"encoding/json"
+	"errors"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
+	"strings"
 	"swift-parser/internal/database"
+	"swift-parser/internal/diff"
+	"swift-parser/internal/importer"
 	"swift-parser/internal/models"
+	"swift-parser/internal/parser"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -21,15 +32,57 @@ type TestResponse struct {
 	Branches      []models.SwiftCode `json:"branches,omitempty"`
 }
 
-func TestGetSWIFTCode(t *testing.T) {
-	gin.SetMode(gin.TestMode)
-	db, err := database.NewDB("host=localhost port=5432 user=postgres password=3107 dbname=postgres sslmode=disable")
+// setupTestStore returns an in-memory store seeded with the test spreadsheet
+func setupTestStore(t *testing.T) *database.MemoryStore {
+	codes, err := parser.ParseExcelFile(filepath.Join("..", "parser", "testdata", "Interns_2025_SWIFT_CODES.xlsx"))
 	if err != nil {
-		t.Fatalf("Failed to connect to database: %v", err)
+		t.Fatalf("Failed to parse test data: %v", err)
+	}
+
+	store := database.NewMemoryStore()
+	if err := store.InsertSwiftCodes(context.Background(), codes); err != nil {
+		t.Fatalf("Failed to seed store: %v", err)
 	}
-	defer db.Close()
+	return store
+}
+
+// serve sends a request to engine and returns the recorded response. The
+// request comes from a fixed client address so actors can be asserted.
+func serve(t *testing.T, engine http.Handler, method, path, body string, headers map[string]string) *httptest.ResponseRecorder {
+	t.Helper()
+	req, err := http.NewRequest(method, path, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	req.RemoteAddr = "192.0.2.1:1234"
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	return w
+}
+
+// upload posts content as the multipart file name to path, authorized with
+// the admin token s3cret. An empty name leaves the file out of the form.
+func upload(t *testing.T, engine http.Handler, path, name, content string) *httptest.ResponseRecorder {
+	t.Helper()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	if name != "" {
+		part, _ := form.CreateFormFile("file", name)
+		part.Write([]byte(content))
+	}
+	form.Close()
+	return serve(t, engine, "POST", path, body.String(), map[string]string{
+		"Authorization": "Bearer s3cret",
+		"Content-Type":  form.FormDataContentType(),
+	})
+}
 
-	router := NewRouter(db)
+func TestGetSWIFTCode(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := NewRouter(setupTestStore(t))
 	engine := router.Setup()
 
 	tests := []struct {
@@ -51,17 +104,38 @@ func TestGetSWIFTCode(t *testing.T) {
 			wantHQ:     false,
 		},
 		{
-			name:       "Invalid SWIFT",
-			swiftCode:  "INVALID123",
+			name:       "Get Headquarter by BIC8",
+			swiftCode:  "ANIBAWA1",
+			wantStatus: http.StatusOK,
+			wantHQ:     true,
+		},
+		{
+			name:       "Get Headquarter in lower case",
+			swiftCode:  "anibawa1xxx",
+			wantStatus: http.StatusOK,
+			wantHQ:     true,
+		},
+		{
+			name:       "Get Headquarter by lower-case BIC8",
+			swiftCode:  "anibawa1",
+			wantStatus: http.StatusOK,
+			wantHQ:     true,
+		},
+		{
+			name:       "Unknown SWIFT",
+			swiftCode:  "INVALID1234",
 			wantStatus: http.StatusNotFound,
 		},
+		{
+			name:       "Invalid SWIFT length",
+			swiftCode:  "INVALID123",
+			wantStatus: http.StatusBadRequest,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			w := httptest.NewRecorder()
-			req, _ := http.NewRequest("GET", "/v1/swift-codes/"+tt.swiftCode, nil)
-			engine.ServeHTTP(w, req)
+			w := serve(t, engine, "GET", "/v1/swift-codes/"+tt.swiftCode, "", nil)
 
 			if w.Code != tt.wantStatus {
 				t.Errorf("want status %d, got %d", tt.wantStatus, w.Code)
@@ -75,7 +149,7 @@ func TestGetSWIFTCode(t *testing.T) {
 				}
 
 				// Validate response fields
-				if response.SwiftCode != tt.swiftCode {
+				if !strings.HasPrefix(response.SwiftCode, strings.ToUpper(tt.swiftCode)) {
 					t.Errorf("want SWIFT code %s, got %s", tt.swiftCode, response.SwiftCode)
 				}
 
@@ -91,3 +165,1029 @@ func TestGetSWIFTCode(t *testing.T) {
 		})
 	}
 }
+
+func TestErrorResponses(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := NewRouter(setupTestStore(t))
+	engine := router.Setup()
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+	}{
+		{
+			name:       "Unknown SWIFT code",
+			method:     "GET",
+			path:       "/v1/swift-codes/MISSPLPWXXX",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "Invalid country code",
+			method:     "GET",
+			path:       "/v1/swift-codes/country/POL",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Country without codes",
+			method:     "GET",
+			path:       "/v1/swift-codes/country/DE",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "Malformed body",
+			method:     "POST",
+			path:       "/v1/swift-codes",
+			body:       "{",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Invalid BIC",
+			method:     "POST",
+			path:       "/v1/swift-codes",
+			body:       `{"swiftCode":"TESTZZ00XXX","countryISO2":"ZZ","countryName":"NOWHERE","bankName":"TEST BANK","isHeadquarter":true}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Duplicate SWIFT code",
+			method:     "POST",
+			path:       "/v1/swift-codes",
+			body:       `{"swiftCode":"ANIBAWA1XXX","countryISO2":"AW","countryName":"ARUBA","bankName":"ARUBA BANK LTD","isHeadquarter":true}`,
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name:       "Delete unknown SWIFT code",
+			method:     "DELETE",
+			path:       "/v1/swift-codes/MISSPLPWXXX",
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(t, engine, tt.method, tt.path, tt.body, nil)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("want status %d, got %d", tt.wantStatus, w.Code)
+			}
+
+			var response ErrorResponse
+			if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+				t.Fatalf("Failed to decode error response: %v", err)
+			}
+			if response.Error == "" {
+				t.Error("want non-empty error message")
+			}
+		})
+	}
+}
+
+func TestPostSWIFTCode(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := setupTestStore(t)
+	engine := NewRouter(store).Setup()
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantFields []string
+	}{
+		{
+			name:       "Lowercase values are normalized",
+			body:       `{"swiftCode":"testtr05xxx","countryISO2":"tr","countryName":"Turkey","bankName":"Test Bank","address":"Test Address","isHeadquarter":true}`,
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name:       "Empty body lists every required field",
+			body:       `{}`,
+			wantStatus: http.StatusBadRequest,
+			wantFields: []string{"swiftCode", "countryISO2", "countryName", "bankName"},
+		},
+		{
+			name:       "Mismatched country and headquarter flag",
+			body:       `{"swiftCode":"TESTTR05001","countryISO2":"PL","countryName":"TURKEY","bankName":"Test Bank","isHeadquarter":true}`,
+			wantStatus: http.StatusBadRequest,
+			wantFields: []string{"countryISO2", "countryName", "isHeadquarter"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(t, engine, "POST", "/v1/swift-codes", tt.body, nil)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("want status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+
+			if tt.wantFields != nil {
+				var response ErrorResponse
+				if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+					t.Fatalf("Failed to decode error response: %v", err)
+				}
+				if len(response.Fields) != len(tt.wantFields) {
+					t.Fatalf("want fields %v, got %+v", tt.wantFields, response.Fields)
+				}
+				for i, field := range tt.wantFields {
+					if response.Fields[i].Field != field {
+						t.Errorf("want field %s at position %d, got %s", field, i, response.Fields[i].Field)
+					}
+				}
+			}
+		})
+	}
+
+	stored, err := store.GetSWIFTCode("TESTTR05XXX")
+	if err != nil {
+		t.Fatalf("Failed to get normalized SWIFT code: %v", err)
+	}
+	if stored.CountryISO2 != "TR" || stored.CountryName != "TURKEY" || stored.BankName != "TEST BANK" {
+		t.Errorf("want upper-case values, got %+v", stored)
+	}
+}
+
+func TestGetSWIFTCodesByCountryPagination(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := setupTestStore(t)
+	engine := NewRouter(store).Setup()
+
+	all, err := store.GetSWIFTCodesByCountry("PL", database.CountryQuery{})
+	if err != nil {
+		t.Fatalf("Failed to list country codes: %v", err)
+	}
+
+	// Without limit or cursor the whole country is listed, as before
+	// pagination
+	w := serve(t, engine, "GET", "/v1/swift-codes/country/PL", "", nil)
+	var unpaginated CountryResponse
+	if err := json.NewDecoder(w.Body).Decode(&unpaginated); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(unpaginated.SwiftCodes) != len(all) || unpaginated.NextCursor != "" {
+		t.Errorf("want all %d codes without a cursor, got %d and %q", len(all), len(unpaginated.SwiftCodes), unpaginated.NextCursor)
+	}
+
+	var seen []BranchResponse
+	path := "/v1/swift-codes/country/PL?limit=7&sort=bankName"
+	for pages := 0; path != ""; pages++ {
+		if pages > len(all) {
+			t.Fatal("pagination did not terminate")
+		}
+
+		w := serve(t, engine, "GET", path, "", nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("want status 200, got %d: %s", w.Code, w.Body.String())
+		}
+
+		var response CountryResponse
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if len(response.SwiftCodes) > 7 {
+			t.Fatalf("want at most 7 codes per page, got %d", len(response.SwiftCodes))
+		}
+		seen = append(seen, response.SwiftCodes...)
+
+		path = ""
+		if response.NextCursor != "" {
+			path = "/v1/swift-codes/country/PL?limit=7&sort=bankName&cursor=" + response.NextCursor
+		}
+	}
+
+	if len(seen) != len(all) {
+		t.Fatalf("want %d codes across pages, got %d", len(all), len(seen))
+	}
+	for i := 1; i < len(seen); i++ {
+		prev, cur := seen[i-1], seen[i]
+		if prev.BankName > cur.BankName || (prev.BankName == cur.BankName && prev.SwiftCode >= cur.SwiftCode) {
+			t.Errorf("codes out of order at %d: %+v before %+v", i, prev, cur)
+		}
+	}
+
+	for _, path := range []string{
+		"/v1/swift-codes/country/PL?limit=0",
+		"/v1/swift-codes/country/PL?sort=address",
+		"/v1/swift-codes/country/PL?isHeadquarter=maybe",
+		"/v1/swift-codes/country/PL?cursor=not-a-cursor",
+	} {
+		w := serve(t, engine, "GET", path, "", nil)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: want status 400, got %d", path, w.Code)
+		}
+	}
+}
+
+func TestSearchSWIFTCodes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := NewRouter(setupTestStore(t)).Setup()
+
+	w := serve(t, engine, "GET", "/v1/swift-codes/search?q=alior&country=pl&isHeadquarter=true", "", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("want status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var response SearchResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(response.Results) != 1 || response.Results[0].SwiftCode != "ALBPPLPWXXX" {
+		t.Errorf("want only ALBPPLPWXXX, got %+v", response.Results)
+	}
+
+	for _, path := range []string{
+		"/v1/swift-codes/search",
+		"/v1/swift-codes/search?q=bank&country=ZZ",
+		"/v1/swift-codes/search?q=bank&limit=500",
+		"/v1/swift-codes/search?q=%2B%2B",
+	} {
+		w := serve(t, engine, "GET", path, "", nil)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: want status 400, got %d", path, w.Code)
+		}
+	}
+}
+
+func TestGetSWIFTCodesByPrefix(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := setupTestStore(t)
+	if err := store.AddSWIFTCode(context.Background(), &models.SwiftCode{
+		SwiftCode: "ORPHPLPW001", CountryISO2: "PL", CountryName: "POLAND", BankName: "ORPHAN BANK",
+	}); err != nil {
+		t.Fatalf("Failed to add SWIFT code: %v", err)
+	}
+	engine := NewRouter(store).Setup()
+
+	get := func(path string) PrefixResponse {
+		t.Helper()
+		w := serve(t, engine, "GET", path, "", nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: want status 200, got %d: %s", path, w.Code, w.Body.String())
+		}
+		var response PrefixResponse
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return response
+	}
+
+	// Branches without a headquarter of the same BIC8 fall back to one
+	// sharing the first 6 characters
+	response := get("/v1/swift-codes/ALBP")
+	if len(response.Headquarters) != 1 || response.Headquarters[0].SwiftCode != "ALBPPLPWXXX" {
+		t.Fatalf("want headquarter ALBPPLPWXXX, got %+v", response.Headquarters)
+	}
+	if got := len(response.Headquarters[0].Branches); got != 2 {
+		t.Errorf("want 2 branches under ALBPPLPWXXX, got %d", got)
+	}
+
+	if response = get("/v1/swift-codes/albppl"); response.Prefix != "ALBPPL" || len(response.Headquarters) != 1 {
+		t.Errorf("want lower-case prefix matched as ALBPPL, got %+v", response)
+	}
+
+	response = get("/v1/swift-codes/BCECCL")
+	if len(response.Headquarters) != 8 {
+		t.Fatalf("want 8 headquarters, got %d", len(response.Headquarters))
+	}
+	for _, hq := range response.Headquarters {
+		wantBranches := 0
+		if hq.SwiftCode == "BCECCLRMXXX" {
+			wantBranches = 4
+		}
+		if len(hq.Branches) != wantBranches {
+			t.Errorf("want %d branches under %s, got %d", wantBranches, hq.SwiftCode, len(hq.Branches))
+		}
+	}
+
+	response = get("/v1/swift-codes/ORPHPL")
+	if len(response.Headquarters) != 0 || len(response.Branches) != 1 || response.Branches[0].SwiftCode != "ORPHPLPW001" {
+		t.Errorf("want only the unaffiliated branch, got %+v", response)
+	}
+
+	w := serve(t, engine, "GET", "/v1/swift-codes/MISS", "", nil)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("want status 404 for unknown prefix, got %d", w.Code)
+	}
+}
+
+func TestUpdateSWIFTCode(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := setupTestStore(t)
+	engine := NewRouter(store).Setup()
+
+	// Requests run in order against the same store
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+		wantFields []string
+	}{
+		{
+			name:       "Replace every field",
+			method:     "PUT",
+			path:       "/v1/swift-codes/anibawa1xxx",
+			body:       `{"countryISO2":"aw","countryName":"Aruba","bankName":"Aruba Bank N.V.","address":"Camacuri 12","isHeadquarter":true}`,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "Patch the address only",
+			method:     "PATCH",
+			path:       "/v1/swift-codes/ANIBAWA1XXX",
+			body:       `{"address":"Camacuri 14"}`,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "Put requires every field",
+			method:     "PUT",
+			path:       "/v1/swift-codes/ANIBAWA1XXX",
+			body:       `{"address":"Camacuri 14"}`,
+			wantStatus: http.StatusBadRequest,
+			wantFields: []string{"countryISO2", "countryName", "bankName", "isHeadquarter"},
+		},
+		{
+			name:       "Put does not clear omitted fields",
+			method:     "PUT",
+			path:       "/v1/swift-codes/ANIBAWA1XXX",
+			body:       `{"countryISO2":"AW","countryName":"ARUBA","bankName":"ARUBA BANK"}`,
+			wantStatus: http.StatusBadRequest,
+			wantFields: []string{"address", "isHeadquarter"},
+		},
+		{
+			name:       "Code cannot change",
+			method:     "PATCH",
+			path:       "/v1/swift-codes/ANIBAWA1XXX",
+			body:       `{"swiftCode":"ANIBAWA2XXX"}`,
+			wantStatus: http.StatusBadRequest,
+			wantFields: []string{"swiftCode"},
+		},
+		{
+			name:       "Patch is validated like create",
+			method:     "PATCH",
+			path:       "/v1/swift-codes/ANIBAWA1XXX",
+			body:       `{"countryName":"NETHERLANDS","isHeadquarter":false}`,
+			wantStatus: http.StatusBadRequest,
+			wantFields: []string{"countryName", "isHeadquarter"},
+		},
+		{
+			name:       "Unknown SWIFT code",
+			method:     "PATCH",
+			path:       "/v1/swift-codes/MISSPLPWXXX",
+			body:       `{"address":"Nowhere"}`,
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(t, engine, tt.method, tt.path, tt.body, nil)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("want status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantFields != nil {
+				var response ErrorResponse
+				if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+					t.Fatalf("Failed to decode error response: %v", err)
+				}
+				if len(response.Fields) != len(tt.wantFields) {
+					t.Fatalf("want fields %v, got %+v", tt.wantFields, response.Fields)
+				}
+				for i, field := range tt.wantFields {
+					if response.Fields[i].Field != field {
+						t.Errorf("want field %s at position %d, got %s", field, i, response.Fields[i].Field)
+					}
+				}
+			}
+		})
+	}
+
+	stored, err := store.GetSWIFTCode("ANIBAWA1XXX")
+	if err != nil {
+		t.Fatalf("Failed to get updated SWIFT code: %v", err)
+	}
+	if stored.BankName != "ARUBA BANK N.V." || stored.Address != "CAMACURI 14" {
+		t.Errorf("want replaced bank name and patched address, got %+v", stored)
+	}
+
+	// The path code is normalized for deletes too
+	w := serve(t, engine, "DELETE", "/v1/swift-codes/bchiclr10r2", "", nil)
+	if w.Code != http.StatusOK {
+		t.Errorf("want lower-case delete to succeed, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestConditionalRequests(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := NewRouter(setupTestStore(t)).Setup()
+
+	const hq = "/v1/swift-codes/ANIBAWA1XXX"
+	w := serve(t, engine, "GET", hq, "", nil)
+	hqTag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || hqTag == "" {
+		t.Fatalf("want status 200 with an ETag, got %d and %q", w.Code, hqTag)
+	}
+	if w := serve(t, engine, "GET", hq, "", map[string]string{"If-None-Match": hqTag}); w.Code != http.StatusNotModified {
+		t.Errorf("want status 304 for matching If-None-Match, got %d", w.Code)
+	}
+
+	// Adding a branch changes the headquarter representation
+	branch := `{"swiftCode":"ANIBAWA1001","countryISO2":"AW","countryName":"ARUBA","bankName":"ARUBA BANK LTD","isHeadquarter":false}`
+	w = serve(t, engine, "POST", "/v1/swift-codes", branch, nil)
+	if w.Code != http.StatusCreated || w.Header().Get("ETag") != `"1"` {
+		t.Fatalf("want status 201 with ETag \"1\", got %d and %q", w.Code, w.Header().Get("ETag"))
+	}
+	if w := serve(t, engine, "GET", hq, "", map[string]string{"If-None-Match": hqTag}); w.Code != http.StatusOK {
+		t.Errorf("want status 200 once a branch is added, got %d", w.Code)
+	}
+
+	const path = "/v1/swift-codes/ANIBAWA1001"
+	update := `{"countryISO2":"AW","countryName":"ARUBA","bankName":"ARUBA BANK LTD","address":"ORANJESTAD","isHeadquarter":false}`
+	w = serve(t, engine, "PUT", path, update, map[string]string{"If-Match": `"1"`})
+	if w.Code != http.StatusOK || w.Header().Get("ETag") != `"2"` {
+		t.Fatalf("want status 200 with ETag \"2\", got %d and %q", w.Code, w.Header().Get("ETag"))
+	}
+
+	// A write on a headquarter returns the tag GET returns
+	w = serve(t, engine, "PATCH", hq, `{"address":"ORANJESTAD"}`, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("want status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := serve(t, engine, "GET", hq, "", map[string]string{"If-None-Match": w.Header().Get("ETag")}); w.Code != http.StatusNotModified {
+		t.Errorf("want status 304 for the ETag of the write, got %d", w.Code)
+	}
+
+	tests := []struct {
+		name       string
+		method     string
+		body       string
+		ifMatch    string
+		wantStatus int
+	}{
+		{"Stale PUT", "PUT", update, `"1"`, http.StatusPreconditionFailed},
+		{"Stale PATCH", "PATCH", `{"address":"NOORD"}`, `"1"`, http.StatusPreconditionFailed},
+		{"Weak tag never matches", "PATCH", `{"address":"NOORD"}`, `W/"2"`, http.StatusPreconditionFailed},
+		{"Several tags", "DELETE", "", `"1", "2"`, http.StatusBadRequest},
+		{"Stale DELETE", "DELETE", "", `"1"`, http.StatusPreconditionFailed},
+		{"Current DELETE", "DELETE", "", `"2"`, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(t, engine, tt.method, path, tt.body, map[string]string{"If-Match": tt.ifMatch})
+			if w.Code != tt.wantStatus {
+				t.Errorf("want status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestGetSWIFTCodeHistory(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := NewRouter(setupTestStore(t), WithAdminToken("s3cret")).Setup()
+
+	requests := []struct {
+		method string
+		path   string
+		body   string
+	}{
+		{"POST", "/v1/swift-codes", `{"swiftCode":"TESTTR05XXX","countryISO2":"TR","countryName":"TURKEY","bankName":"TEST BANK","isHeadquarter":true}`},
+		{"PATCH", "/v1/swift-codes/TESTTR05XXX", `{"address":"ISTANBUL"}`},
+		{"DELETE", "/v1/swift-codes/TESTTR05XXX", ""},
+		{"POST", "/v1/swift-codes/TESTTR05XXX/restore", ""},
+	}
+	for _, tt := range requests {
+		headers := map[string]string{actorHeader: "compliance-bot"}
+		if strings.HasSuffix(tt.path, "/restore") {
+			headers["Authorization"] = "Bearer s3cret"
+		}
+		w := serve(t, engine, tt.method, tt.path, tt.body, headers)
+		if w.Code >= 300 {
+			t.Fatalf("%s %s: want success, got %d: %s", tt.method, tt.path, w.Code, w.Body.String())
+		}
+	}
+
+	w := serve(t, engine, "GET", "/v1/swift-codes/testtr05/history", "", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("want status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var response HistoryResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.SwiftCode != "TESTTR05XXX" || len(response.History) != 4 {
+		t.Fatalf("want 4 entries for TESTTR05XXX, got %+v", response)
+	}
+	update := response.History[1]
+	if update.Operation != "update" || update.OldValues.Address != "" || update.NewValues.Address != "ISTANBUL" {
+		t.Errorf("want address update, got %+v", update)
+	}
+	// Only the admin request is trusted to name its actor
+	for _, entry := range response.History[:3] {
+		if entry.Actor != "192.0.2.1 (claimed: compliance-bot)" || entry.Source != "api" {
+			t.Errorf("want client IP with the claimed actor from api, got %q from %q", entry.Actor, entry.Source)
+		}
+	}
+	if restore := response.History[3]; restore.Actor != "compliance-bot" {
+		t.Errorf("want admin restore attributed to compliance-bot, got %q", restore.Actor)
+	}
+
+	w = serve(t, engine, "GET", "/v1/swift-codes/MISSPLPWXXX/history", "", nil)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("want status 404 for code without history, got %d", w.Code)
+	}
+}
+
+func TestGetSWIFTCodeAsOf(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := NewRouter(setupTestStore(t)).Setup()
+
+	// The seeded branch exists before the snapshot time and is deleted after
+	time.Sleep(time.Millisecond)
+	before := time.Now().UTC().Format(time.RFC3339Nano)
+	time.Sleep(time.Millisecond)
+	if w := serve(t, engine, "PATCH", "/v1/swift-codes/ANIBAWA1XXX", `{"address":"ORANJESTAD"}`, nil); w.Code != http.StatusOK {
+		t.Fatalf("Failed to patch SWIFT code: %d %s", w.Code, w.Body.String())
+	}
+	if w := serve(t, engine, "DELETE", "/v1/swift-codes/BCHICLR10R2", "", nil); w.Code != http.StatusOK {
+		t.Fatalf("Failed to delete SWIFT code: %d %s", w.Code, w.Body.String())
+	}
+
+	w := serve(t, engine, "GET", "/v1/swift-codes/ANIBAWA1XXX?asOf="+before, "", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("want status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var hq HeadquarterResponse
+	if err := json.NewDecoder(w.Body).Decode(&hq); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if hq.Address == "ORANJESTAD" {
+		t.Errorf("want address before the patch, got %q", hq.Address)
+	}
+	if w.Header().Get("ETag") != "" {
+		t.Error("want no ETag for a historic record")
+	}
+
+	if w := serve(t, engine, "GET", "/v1/swift-codes/BCHICLR10R2?asOf="+before, "", nil); w.Code != http.StatusOK {
+		t.Errorf("want deleted branch as of before the delete, got %d", w.Code)
+	}
+	if w := serve(t, engine, "GET", "/v1/swift-codes/BCHICLR10R2", "", nil); w.Code != http.StatusNotFound {
+		t.Errorf("want deleted branch gone now, got %d", w.Code)
+	}
+
+	w = serve(t, engine, "GET", "/v1/swift-codes/country/CL?asOf="+before+"&isHeadquarter=false", "", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("want status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var country CountryResponse
+	if err := json.NewDecoder(w.Body).Decode(&country); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	found := false
+	for _, code := range country.SwiftCodes {
+		found = found || code.SwiftCode == "BCHICLR10R2"
+	}
+	if !found {
+		t.Error("want deleted branch listed in the country as of before the delete")
+	}
+
+	for _, path := range []string{
+		"/v1/swift-codes/ANIBAWA1XXX?asOf=yesterday",
+		"/v1/swift-codes/ANIB?asOf=2025-01-01",
+		"/v1/swift-codes/country/CL?asOf=01/02/2025",
+	} {
+		if w := serve(t, engine, "GET", path, "", nil); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: want status 400, got %d", path, w.Code)
+		}
+	}
+	if w := serve(t, engine, "GET", "/v1/swift-codes/ANIBAWA1XXX?asOf=2000-01-01", "", nil); w.Code != http.StatusNotFound {
+		t.Errorf("want status 404 before the code existed, got %d", w.Code)
+	}
+}
+
+func TestSoftDeleteAndRestore(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := NewRouter(setupTestStore(t), WithAdminToken("s3cret")).Setup()
+	admin := map[string]string{"Authorization": "Bearer s3cret"}
+
+	if w := serve(t, engine, "DELETE", "/v1/swift-codes/BCHICLR10R2", "", nil); w.Code != http.StatusOK {
+		t.Fatalf("want status 200 for delete, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := serve(t, engine, "GET", "/v1/swift-codes/BCHICLR10R2", "", nil); w.Code != http.StatusNotFound {
+		t.Errorf("want status 404 for deleted code, got %d", w.Code)
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		token  string
+		status int
+	}{
+		{"List without token", "GET", "/v1/admin/swift-codes/deleted", "", http.StatusUnauthorized},
+		{"List with wrong token", "GET", "/v1/admin/swift-codes/deleted", "guess", http.StatusUnauthorized},
+		{"Restore without token", "POST", "/v1/swift-codes/BCHICLR10R2/restore", "", http.StatusUnauthorized},
+		{"Restore live code", "POST", "/v1/swift-codes/ANIBAWA1XXX/restore", "s3cret", http.StatusConflict},
+		{"Restore unknown code", "POST", "/v1/swift-codes/MISSPLPWXXX/restore", "s3cret", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var headers map[string]string
+			if tt.token != "" {
+				headers = map[string]string{"Authorization": "Bearer " + tt.token}
+			}
+			if w := serve(t, engine, tt.method, tt.path, "", headers); w.Code != tt.status {
+				t.Errorf("want status %d, got %d: %s", tt.status, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	w := serve(t, engine, "GET", "/v1/admin/swift-codes/deleted", "", admin)
+	if w.Code != http.StatusOK {
+		t.Fatalf("want status 200 for deleted list, got %d: %s", w.Code, w.Body.String())
+	}
+	var deleted DeletedSwiftCodesResponse
+	if err := json.NewDecoder(w.Body).Decode(&deleted); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(deleted.SwiftCodes) != 1 || deleted.SwiftCodes[0].SwiftCode != "BCHICLR10R2" {
+		t.Fatalf("want BCHICLR10R2 listed as deleted, got %+v", deleted)
+	}
+	entry := deleted.SwiftCodes[0]
+	if entry.RestorableUntil == nil || !entry.RestorableUntil.Equal(entry.DeletedAt.Add(DefaultRetention)) {
+		t.Errorf("want restorable until %s after deletion, got %v", DefaultRetention, entry.RestorableUntil)
+	}
+
+	if w := serve(t, engine, "POST", "/v1/swift-codes/bchiclr10r2/restore", "", admin); w.Code != http.StatusOK || w.Header().Get("ETag") == "" {
+		t.Fatalf("want status 200 with ETag for restore, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := serve(t, engine, "GET", "/v1/swift-codes/BCHICLR10R2", "", nil); w.Code != http.StatusOK {
+		t.Errorf("want status 200 for restored code, got %d", w.Code)
+	}
+
+	disabled := NewRouter(setupTestStore(t)).Setup()
+	w = serve(t, disabled, "GET", "/v1/admin/swift-codes/deleted", "", map[string]string{"Authorization": "Bearer "})
+	if w.Code != http.StatusForbidden {
+		t.Errorf("want status 403 without an admin token configured, got %d", w.Code)
+	}
+}
+
+func TestDeleteHeadquarterBranchPolicy(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := NewRouter(setupTestStore(t)).Setup()
+
+	if w := serve(t, engine, "DELETE", "/v1/swift-codes/ALBPPLPWXXX?branches=orphan", "", nil); w.Code != http.StatusBadRequest {
+		t.Errorf("want status 400 for unknown policy, got %d", w.Code)
+	}
+
+	w := serve(t, engine, "DELETE", "/v1/swift-codes/ALBPPLPWXXX", "", nil)
+	if w.Code != http.StatusConflict {
+		t.Fatalf("want status 409 for headquarter with branches, got %d: %s", w.Code, w.Body.String())
+	}
+	var response ErrorResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if strings.Join(response.Branches, ",") != "ALBPPLP1BMW,ALBPPLPWCUS" {
+		t.Errorf("want both branches listed, got %v", response.Branches)
+	}
+
+	if w := serve(t, engine, "DELETE", "/v1/swift-codes/ALBPPLPWXXX?branches=cascade", "", nil); w.Code != http.StatusOK {
+		t.Fatalf("want status 200 for cascade, got %d: %s", w.Code, w.Body.String())
+	}
+	for _, code := range []string{"ALBPPLPWXXX", "ALBPPLP1BMW", "ALBPPLPWCUS"} {
+		if w := serve(t, engine, "GET", "/v1/swift-codes/"+code, "", nil); w.Code != http.StatusNotFound {
+			t.Errorf("want %s deleted by cascade, got %d", code, w.Code)
+		}
+	}
+
+	if w := serve(t, engine, "DELETE", "/v1/swift-codes/ANIBAWA1XXX?branches=detach", "", nil); w.Code != http.StatusOK {
+		t.Errorf("want status 200 for detach, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestImportSWIFTCodes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := setupTestStore(t)
+	engine := NewRouter(store, WithAdminToken("s3cret"), WithMaxUploadSize(4096)).Setup()
+
+	const path = "/v1/swift-codes/import"
+	aruba, err := store.GetSWIFTCode("ANIBAWA1XXX")
+	if err != nil {
+		t.Fatalf("Failed to get seeded code: %v", err)
+	}
+	csv := "SWIFT CODE,COUNTRY ISO2 CODE,NAME,ADDRESS,COUNTRY NAME\n" +
+		"ANIBAWA1XXX,AW,ARUBA BANK N.V.," + aruba.Address + ",ARUBA\n" +
+		"TESTTR05XXX,TR,TEST BANK,ISTANBUL,TURKEY\n" +
+		"TESTXX05XXX,XX,BAD BANK,,NOWHERE\n"
+
+	if w := upload(t, engine, path, "", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("want status 400 without a file, got %d", w.Code)
+	}
+	if w := upload(t, engine, path, "codes.csv", "CODE,NAME\nX,Y\n"); w.Code != http.StatusBadRequest {
+		t.Errorf("want status 400 for missing columns, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := upload(t, engine, path, "codes.csv", strings.Repeat("X", 8192)); w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("want status 413 above the upload limit, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w := upload(t, engine, path+"?dryRun=true", "codes.csv", csv)
+	if w.Code != http.StatusOK {
+		t.Fatalf("want status 200 for dry run, got %d: %s", w.Code, w.Body.String())
+	}
+	var response ImportResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.Report.Accepted != 2 || response.Report.Rejected != 1 || len(response.Report.Errors) != 1 {
+		t.Errorf("want 2 accepted and 1 rejected rows, got %+v", response.Report)
+	}
+	if response.Diff == nil || response.Diff.Added != 1 || response.Diff.Modified != 1 || len(response.Diff.Changes) != 2 {
+		t.Fatalf("want 1 added and 1 modified code, got %+v", response.Diff)
+	}
+	modified := response.Diff.Changes[0]
+	if modified.SwiftCode != "ANIBAWA1XXX" || modified.Kind != diff.Modified ||
+		len(modified.Fields) != 1 || modified.Fields[0].Field != "bankName" {
+		t.Errorf("want bankName of ANIBAWA1XXX modified, got %+v", modified)
+	}
+	if _, err := store.GetSWIFTCode("TESTTR05XXX"); err == nil {
+		t.Errorf("want nothing written by a dry run")
+	}
+
+	if w := upload(t, engine, path, "codes.csv", csv); w.Code != http.StatusOK {
+		t.Fatalf("want status 200 for import, got %d: %s", w.Code, w.Body.String())
+	}
+	if _, err := store.GetSWIFTCode("TESTTR05XXX"); err != nil {
+		t.Errorf("want TESTTR05XXX imported, got %v", err)
+	}
+	history, _ := store.GetHistory("ANIBAWA1XXX")
+	if last := history[len(history)-1]; last.Source != "import:codes.csv" || last.NewValues.BankName != "ARUBA BANK N.V." {
+		t.Errorf("want update recorded from import:codes.csv, got %+v", last)
+	}
+}
+
+func TestImportSync(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := setupTestStore(t)
+	engine := NewRouter(store, WithAdminToken("s3cret")).Setup()
+
+	all, err := store.ListSWIFTCodes()
+	if err != nil {
+		t.Fatalf("Failed to list codes: %v", err)
+	}
+	var aruba []string
+	for _, code := range all {
+		if code.CountryISO2 == "AW" {
+			aruba = append(aruba, code.SwiftCode)
+		}
+	}
+	if len(aruba) == 0 {
+		t.Fatal("want seeded codes for AW")
+	}
+	csv := "SWIFT CODE,COUNTRY ISO2 CODE,NAME,ADDRESS,COUNTRY NAME\n" +
+		"TESTAW05XXX,AW,TEST BANK,ORANJESTAD,ARUBA\n" +
+		"TESTTR05XXX,TR,TEST BANK,ISTANBUL,TURKEY\n"
+
+	if w := upload(t, engine, "/v1/swift-codes/import?country=AW", "aruba.csv", csv); w.Code != http.StatusBadRequest {
+		t.Errorf("want status 400 for country without sync, got %d", w.Code)
+	}
+	if w := upload(t, engine, "/v1/swift-codes/import?sync=true&country=ZZ", "aruba.csv", csv); w.Code != http.StatusBadRequest {
+		t.Errorf("want status 400 for unknown country, got %d", w.Code)
+	}
+
+	w := upload(t, engine, "/v1/swift-codes/import?sync=true&country=AW&dryRun=true", "aruba.csv", csv)
+	var response ImportResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("want status 200 for dry run, got %d: %v", w.Code, err)
+	}
+	if response.Diff == nil || response.Diff.Added != 1 || response.Diff.Removed != len(aruba) {
+		t.Errorf("want 1 added and %d removed codes, got %+v", len(aruba), response.Diff)
+	}
+
+	if w := upload(t, engine, "/v1/swift-codes/import?sync=true&country=AW", "aruba.csv", csv); w.Code != http.StatusConflict {
+		t.Errorf("want status 409 above the removal threshold, got %d: %s", w.Code, w.Body.String())
+	}
+	if _, err := store.GetSWIFTCode(aruba[0]); err != nil {
+		t.Errorf("want nothing removed by a refused sync, got %v", err)
+	}
+
+	w = upload(t, engine, "/v1/swift-codes/import?sync=true&country=AW&maxRemovedPercent=100", "aruba.csv", csv)
+	response = ImportResponse{}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("want status 200 for sync, got %d: %v", w.Code, err)
+	}
+	if response.Sync == nil || response.Sync.Upserted != 1 || response.Sync.OutOfScope != 1 ||
+		len(response.Sync.Removed) != len(aruba) {
+		t.Errorf("want 1 upserted, 1 out of scope and %d removed, got %+v", len(aruba), response.Sync)
+	}
+	if _, err := store.GetSWIFTCode(aruba[0]); err == nil {
+		t.Errorf("want %s removed by the sync", aruba[0])
+	}
+	if _, err := store.GetSWIFTCode("TESTTR05XXX"); err == nil {
+		t.Errorf("want out of scope TESTTR05XXX left out")
+	}
+	if _, err := store.GetSWIFTCode("BCHICLR10R2"); err != nil {
+		t.Errorf("want other countries untouched, got %v", err)
+	}
+}
+
+func TestImportSyncKeepsRejectedRows(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := setupTestStore(t)
+	engine := NewRouter(store, WithAdminToken("s3cret")).Setup()
+
+	all, err := store.ListSWIFTCodes()
+	if err != nil {
+		t.Fatalf("Failed to list codes: %v", err)
+	}
+	var body bytes.Buffer
+	rows := csv.NewWriter(&body)
+	rows.Write(parser.Header())
+	rejected := ""
+	for _, code := range all {
+		if code.CountryISO2 != "PL" {
+			continue
+		}
+		if rejected == "" {
+			// A country mismatch rejects the row, but the code is still listed
+			rejected = code.SwiftCode
+			code.CountryISO2 = "DE"
+		}
+		rows.Write(parser.Record(code))
+	}
+	rows.Flush()
+
+	sync := func(query string) ImportResponse {
+		t.Helper()
+		w := upload(t, engine, "/v1/swift-codes/import"+query, "poland.csv", body.String())
+		var response ImportResponse
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil || w.Code != http.StatusOK {
+			t.Fatalf("want status 200 for %s, got %d: %v", query, w.Code, err)
+		}
+		return response
+	}
+
+	response := sync("?sync=true&country=PL&dryRun=true")
+	if response.Report.Rejected != 1 || response.Diff.Removed != 0 {
+		t.Errorf("want 1 rejected row and no removals, got %+v and %+v", response.Report, response.Diff)
+	}
+
+	response = sync("?sync=true&country=PL")
+	if response.Sync == nil || len(response.Sync.Removed) != 0 {
+		t.Errorf("want no codes removed, got %+v", response.Sync)
+	}
+	if _, err := store.GetSWIFTCode(rejected); err != nil {
+		t.Errorf("want %s kept, got %v", rejected, err)
+	}
+}
+
+func TestImportJobs(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := setupTestStore(t)
+	runner := importer.NewRunner(store, store, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go runner.Run(ctx)
+	engine := NewRouter(store, WithAdminToken("s3cret"), WithImporter(runner), WithMaxUploadSize(4096)).Setup()
+
+	admin := map[string]string{"Authorization": "Bearer s3cret"}
+
+	if w := upload(t, engine, "/v1/imports", "large.csv", strings.Repeat("X", 8192)); w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("want status 413 above the upload limit, got %d: %s", w.Code, w.Body.String())
+	}
+	if _, err := store.GetImportJob(1); !errors.Is(err, database.ErrNotFound) {
+		t.Errorf("want no job queued for a refused upload, got %v", err)
+	}
+
+	w := upload(t, engine, "/v1/imports", "codes.csv",
+		"SWIFT CODE,COUNTRY ISO2 CODE,NAME,ADDRESS,COUNTRY NAME\nTESTTR05XXX,TR,TEST BANK,ISTANBUL,TURKEY\n")
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("want status 202, got %d: %s", w.Code, w.Body.String())
+	}
+	var job ImportJobResponse
+	if err := json.NewDecoder(w.Body).Decode(&job); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	location := w.Header().Get("Location")
+	if location == "" || job.FileName != "codes.csv" {
+		t.Fatalf("want job for codes.csv with a Location, got %+v at %q", job, location)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for job.Status != database.JobSucceeded && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		w = serve(t, engine, "GET", location, "", admin)
+		if err := json.NewDecoder(w.Body).Decode(&job); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+	}
+	if job.Status != database.JobSucceeded || job.Imported != 1 {
+		t.Fatalf("want job succeeded with 1 code imported, got %+v", job)
+	}
+	if _, err := store.GetSWIFTCode("TESTTR05XXX"); err != nil {
+		t.Errorf("want TESTTR05XXX imported, got %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		status int
+	}{
+		{"Cancel finished job", "DELETE", location, http.StatusConflict},
+		{"Unknown job", "GET", "/v1/imports/999", http.StatusNotFound},
+		{"Invalid job ID", "GET", "/v1/imports/abc", http.StatusBadRequest},
+		{"Upload without file", "POST", "/v1/imports", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := serve(t, engine, tt.method, tt.path, "", admin); w.Code != tt.status {
+				t.Errorf("want status %d, got %d: %s", tt.status, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	disabled := NewRouter(store, WithAdminToken("s3cret")).Setup()
+	w = serve(t, disabled, "GET", location, "", admin)
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("want status 503 without an importer, got %d", w.Code)
+	}
+}
+
+func TestExportSWIFTCodes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := setupTestStore(t)
+	engine := NewRouter(store).Setup()
+
+	all, err := store.ListSWIFTCodes()
+	if err != nil {
+		t.Fatalf("Failed to list codes: %v", err)
+	}
+	var polish []models.SwiftCode
+	for _, code := range all {
+		if code.CountryISO2 == "PL" {
+			polish = append(polish, code)
+		}
+	}
+
+	for _, query := range []string{"?format=pdf", "?country=ZZ", "?isHeadquarter=maybe"} {
+		if w := serve(t, engine, "GET", "/v1/swift-codes/export"+query, "", nil); w.Code != http.StatusBadRequest {
+			t.Errorf("want status 400 for %s, got %d", query, w.Code)
+		}
+	}
+
+	w := serve(t, engine, "GET", "/v1/swift-codes/export"+"?country=pl", "", nil)
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/csv") {
+		t.Fatalf("want CSV with status 200, got %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	if lines[0] != "COUNTRY ISO2 CODE,SWIFT CODE,NAME,ADDRESS,COUNTRY NAME" || len(lines) != len(polish)+1 {
+		t.Errorf("want a header and %d rows, got %d lines starting with %q", len(polish), len(lines), lines[0])
+	}
+
+	w = serve(t, engine, "GET", "/v1/swift-codes/export"+"?format=ndjson&country=PL&isHeadquarter=true", "", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("want status 200 for NDJSON, got %d", w.Code)
+	}
+	dec := json.NewDecoder(w.Body)
+	headquarters := 0
+	for dec.More() {
+		var code SwiftCodeValues
+		if err := dec.Decode(&code); err != nil {
+			t.Fatalf("Failed to decode line: %v", err)
+		}
+		if !code.IsHeadquarter || code.CountryISO2 != "PL" {
+			t.Errorf("want only Polish headquarters, got %+v", code)
+		}
+		headquarters++
+	}
+	if headquarters == 0 || headquarters >= len(polish) {
+		t.Errorf("want some but not all of %d Polish codes, got %d", len(polish), headquarters)
+	}
+
+	w = serve(t, engine, "GET", "/v1/swift-codes/export"+"?format=ndjson&country=AQ", "", nil)
+	if w.Code != http.StatusOK || w.Body.Len() != 0 {
+		t.Errorf("want an empty export for a country without codes, got %d: %q", w.Code, w.Body.String())
+	}
+
+	w = serve(t, engine, "GET", "/v1/swift-codes/export"+"?format=xlsx", "", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("want status 200 for XLSX, got %d: %s", w.Code, w.Body.String())
+	}
+	var reimported []models.SwiftCode
+	report, err := parser.IterateReader(context.Background(), w.Body, "swift-codes.xlsx", parser.Options{}, func(code models.SwiftCode) error {
+		reimported = append(reimported, code)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to parse export: %v", err)
+	}
+	if report.HasRejections() || len(report.Skipped) > 0 {
+		t.Errorf("want every exported row accepted, got %s", report.Summary())
+	}
+	if result := diff.Compare(all, reimported); result.Count(diff.Unchanged) != len(all) {
+		t.Errorf("want export to re-import unchanged, got %s", result.Summary())
+	}
+}
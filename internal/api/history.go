@@ -0,0 +1,120 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"swift-parser/internal/database"
+	"swift-parser/internal/models"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// actorHeader names the caller recorded in the change history. It is only
+// trusted on admin requests; other requests are attributed to the client
+// IP, with the claimed name next to it.
+const actorHeader = "X-Actor"
+
+// SwiftCodeValues is a snapshot of a SWIFT code in a history entry
+type SwiftCodeValues struct {
+	Address       string `json:"address"`
+	BankName      string `json:"bankName"`
+	CountryISO2   string `json:"countryISO2"`
+	CountryName   string `json:"countryName"`
+	IsHeadquarter bool   `json:"isHeadquarter"`
+	SwiftCode     string `json:"swiftCode"`
+}
+
+type HistoryEntryResponse struct {
+	Operation database.Operation `json:"operation"`
+	ChangedAt time.Time          `json:"changedAt"`
+	Actor     string             `json:"actor,omitempty"`
+	Source    string             `json:"source,omitempty"`
+	OldValues *SwiftCodeValues   `json:"oldValues,omitempty"`
+	NewValues *SwiftCodeValues   `json:"newValues,omitempty"`
+}
+
+type HistoryResponse struct {
+	SwiftCode string                 `json:"swiftCode"`
+	History   []HistoryEntryResponse `json:"history"`
+}
+
+// GetSWIFTCodeHistory lists every recorded change to a SWIFT code, oldest
+// first, including changes made before it was deleted
+func (r *Router) GetSWIFTCodeHistory(c *gin.Context) {
+	swiftCode := pathSwiftCode(c)
+
+	entries, err := r.store.GetHistory(swiftCode)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	response := HistoryResponse{
+		SwiftCode: swiftCode,
+		History:   make([]HistoryEntryResponse, len(entries)),
+	}
+	for i, entry := range entries {
+		response.History[i] = HistoryEntryResponse{
+			Operation: entry.Operation,
+			ChangedAt: entry.ChangedAt,
+			Actor:     entry.Actor,
+			Source:    entry.Source,
+			OldValues: valuesOf(entry.OldValues),
+			NewValues: valuesOf(entry.NewValues),
+		}
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+func valuesOf(code *models.SwiftCode) *SwiftCodeValues {
+	if code == nil {
+		return nil
+	}
+	return &SwiftCodeValues{
+		Address:       code.Address,
+		BankName:      code.BankName,
+		CountryISO2:   code.CountryISO2,
+		CountryName:   code.CountryName,
+		IsHeadquarter: code.IsHeadquarter,
+		SwiftCode:     code.SwiftCode,
+	}
+}
+
+// parseAsOf reads the asOf query parameter, either an RFC 3339 timestamp or
+// a date, meaning midnight UTC at its start. It returns the zero time when
+// the parameter is absent.
+func parseAsOf(c *gin.Context, verr *ValidationError) time.Time {
+	raw := c.Query("asOf")
+	if raw == "" {
+		return time.Time{}
+	}
+	if t, err := time.Parse(time.RFC3339Nano, raw); err == nil {
+		return t
+	}
+	if t, err := time.Parse(time.DateOnly, raw); err == nil {
+		return t
+	}
+	verr.add("asOf", "must be an RFC 3339 timestamp or a YYYY-MM-DD date")
+	return time.Time{}
+}
+
+// auditContext returns the request context tagged with the caller, so
+// writes made on its behalf are attributed in the change history
+func auditContext(c *gin.Context) context.Context {
+	return database.WithAudit(c.Request.Context(), database.Audit{Actor: actorOf(c), Source: database.SourceAPI})
+}
+
+// actorOf names the caller of a request for the change history
+func actorOf(c *gin.Context) string {
+	actor := c.GetHeader(actorHeader)
+	switch {
+	case actor == "":
+		return c.ClientIP()
+	case c.GetBool(adminKey):
+		return actor
+	default:
+		return fmt.Sprintf("%s (claimed: %s)", c.ClientIP(), actor)
+	}
+}
@@ -0,0 +1,275 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"swift-parser/internal/database"
+	"swift-parser/internal/diff"
+	"swift-parser/internal/models"
+	"swift-parser/internal/parser"
+	"swift-parser/pkg/validator"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// importFormField is the multipart field holding the uploaded file
+	importFormField = "file"
+	// maxReportedErrors caps the rejected rows listed in an import response
+	maxReportedErrors = 100
+)
+
+// ImportReport counts the rows of an imported file by outcome and lists
+// the first rejected ones
+type ImportReport struct {
+	Accepted int               `json:"accepted"`
+	Skipped  int               `json:"skipped"`
+	Rejected int               `json:"rejected"`
+	Errors   []parser.RowIssue `json:"errors"`
+}
+
+func importReport(report *parser.Report) *ImportReport {
+	errs := report.Rejected
+	if len(errs) > maxReportedErrors {
+		errs = errs[:maxReportedErrors]
+	}
+	return &ImportReport{
+		Accepted: report.AcceptedCount,
+		Skipped:  len(report.Skipped),
+		Rejected: len(report.Rejected),
+		Errors:   append([]parser.RowIssue{}, errs...),
+	}
+}
+
+// ImportDiff summarizes how an import would change the stored codes.
+// Changes lists every added, modified or, when syncing, removed code.
+type ImportDiff struct {
+	Added     int           `json:"added"`
+	Modified  int           `json:"modified"`
+	Removed   int           `json:"removed"`
+	Unchanged int           `json:"unchanged"`
+	Changes   []diff.Change `json:"changes"`
+}
+
+// SyncResponse reports the outcome of a sync import
+type SyncResponse struct {
+	Countries  []string `json:"countries,omitempty"`
+	Upserted   int      `json:"upserted"`
+	OutOfScope int      `json:"outOfScope"`
+	Removed    []string `json:"removed"`
+}
+
+type ImportResponse struct {
+	File   string        `json:"file"`
+	DryRun bool          `json:"dryRun"`
+	Report *ImportReport `json:"report"`
+	// Diff is only returned for dry runs
+	Diff *ImportDiff `json:"diff,omitempty"`
+	// Sync is only returned when a sync was applied
+	Sync *SyncResponse `json:"sync,omitempty"`
+}
+
+// importParams are the query parameters of an import
+type importParams struct {
+	dryRun bool
+	sync   bool
+	scope  database.SyncOptions
+}
+
+func parseImportParams(c *gin.Context, verr *ValidationError) importParams {
+	p := importParams{scope: database.SyncOptions{MaxRemovedPercent: database.DefaultMaxRemovedPercent}}
+	parseBool := func(field string, dst *bool) {
+		if raw := c.Query(field); raw != "" {
+			v, err := strconv.ParseBool(raw)
+			if err != nil {
+				verr.add(field, "must be true or false")
+			}
+			*dst = v
+		}
+	}
+	parseBool("dryRun", &p.dryRun)
+	parseBool("sync", &p.sync)
+
+	for _, raw := range c.QueryArray("country") {
+		for _, country := range strings.Split(raw, ",") {
+			country = strings.ToUpper(strings.TrimSpace(country))
+			if !validator.IsKnownCountry(country) {
+				verr.add("country", "%q is not an ISO 3166-1 country code", country)
+				continue
+			}
+			p.scope.Countries = append(p.scope.Countries, country)
+		}
+	}
+	if raw := c.Query("maxRemovedPercent"); raw != "" {
+		v, err := strconv.ParseFloat(raw, 64)
+		if err != nil || v < 0 || v > 100 {
+			verr.add("maxRemovedPercent", "must be a number between 0 and 100")
+		}
+		p.scope.MaxRemovedPercent = v
+	}
+
+	if !p.sync && (len(p.scope.Countries) > 0 || c.Query("maxRemovedPercent") != "") {
+		verr.add("sync", "country and maxRemovedPercent require sync=true")
+	}
+	return p
+}
+
+// ImportSWIFTCodes parses an uploaded Excel, CSV or TSV file and upserts
+// every accepted row. Rejected rows are reported and left out. With
+// sync=true the file is authoritative for the whole table, or the given
+// countries: stored codes missing from it are deleted, unless more than
+// maxRemovedPercent of them would be. With dryRun=true nothing is written
+// and the response lists what would change.
+func (r *Router) ImportSWIFTCodes(c *gin.Context) {
+	verr := &ValidationError{}
+	params := parseImportParams(c, verr)
+	header, err := r.uploadedFile(c, verr)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	if len(verr.Fields) > 0 {
+		respondError(c, verr)
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	defer file.Close()
+
+	name := filepath.Base(header.Filename)
+	var codes []models.SwiftCode
+	report, err := parser.IterateReader(c.Request.Context(), file, name, parser.Options{}, func(code models.SwiftCode) error {
+		codes = append(codes, code)
+		return nil
+	})
+	if err != nil {
+		if c.Request.Context().Err() == nil {
+			err = fmt.Errorf("%v: %w", err, database.ErrInvalidInput)
+		}
+		respondError(c, err)
+		return
+	}
+
+	// Rejected rows are still part of the source, so a sync keeps their codes
+	params.scope.Keep = report.RejectedCodes()
+
+	response := ImportResponse{File: name, DryRun: params.dryRun, Report: importReport(report)}
+	if params.dryRun {
+		if response.Diff, err = r.importDiff(codes, params); err != nil {
+			respondError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	ctx := database.WithAudit(c.Request.Context(), database.Audit{
+		Actor:  actorOf(c),
+		Source: database.ImportSource(name),
+	})
+	if !params.sync {
+		if err := r.store.InsertSwiftCodes(ctx, codes); err != nil {
+			respondError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	result, err := r.store.SyncSWIFTCodes(ctx, codes, params.scope)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	response.Sync = &SyncResponse{
+		Countries:  params.scope.Countries,
+		Upserted:   result.Upserted,
+		OutOfScope: result.OutOfScope,
+		Removed:    nonNilStrings(result.Removed),
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// uploadedFile returns the file uploaded as importFormField, adding a field
+// error to verr when there is none. It fails if the request body is larger
+// than the upload limit.
+func (r *Router) uploadedFile(c *gin.Context, verr *ValidationError) (*multipart.FileHeader, error) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, r.maxUpload)
+	header, err := c.FormFile(importFormField)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return nil, fmt.Errorf("upload is larger than %d bytes: %w", tooLarge.Limit, err)
+	}
+	if err != nil {
+		verr.add(importFormField, "a multipart file upload is required")
+		return nil, nil
+	}
+	return header, nil
+}
+
+// importDiff compares parsed codes with the stored ones. A plain import
+// only upserts, so stored codes missing from the file are only reported
+// when syncing, within its scope.
+func (r *Router) importDiff(codes []models.SwiftCode, params importParams) (*ImportDiff, error) {
+	current, err := r.store.ListSWIFTCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	var result *diff.Result
+	if params.sync {
+		result = diff.Compare(inScope(current, params.scope), inScope(codes, params.scope))
+		result = withoutKept(result, params.scope.Keep)
+	} else {
+		result = diff.Compare(current, codes).Without(diff.Removed)
+	}
+	return &ImportDiff{
+		Added:     result.Count(diff.Added),
+		Modified:  result.Count(diff.Modified),
+		Removed:   result.Count(diff.Removed),
+		Unchanged: result.Count(diff.Unchanged),
+		Changes:   result.Without(diff.Unchanged).Changes,
+	}, nil
+}
+
+// withoutKept leaves out the removal of codes a sync keeps
+func withoutKept(result *diff.Result, keep []string) *diff.Result {
+	kept := make(map[string]bool, len(keep))
+	for _, code := range keep {
+		kept[code] = true
+	}
+	filtered := &diff.Result{Changes: make([]diff.Change, 0, len(result.Changes))}
+	for _, change := range result.Changes {
+		if change.Kind != diff.Removed || !kept[change.SwiftCode] {
+			filtered.Changes = append(filtered.Changes, change)
+		}
+	}
+	return filtered
+}
+
+// inScope returns the codes in the scope of a sync
+func inScope(codes []models.SwiftCode, scope database.SyncOptions) []models.SwiftCode {
+	var scoped []models.SwiftCode
+	for _, code := range codes {
+		if scope.InScope(code) {
+			scoped = append(scoped, code)
+		}
+	}
+	return scoped
+}
+
+func nonNilStrings(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
@@ -0,0 +1,151 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"swift-parser/internal/database"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ImportJobResponse struct {
+	ID         int64              `json:"id"`
+	FileName   string             `json:"fileName"`
+	Actor      string             `json:"actor,omitempty"`
+	Status     database.JobStatus `json:"status"`
+	Attempts   int                `json:"attempts"`
+	Imported   int                `json:"imported"`
+	Accepted   int                `json:"accepted"`
+	Skipped    int                `json:"skipped"`
+	Rejected   int                `json:"rejected"`
+	Errors     []string           `json:"errors"`
+	Error      string             `json:"error,omitempty"`
+	CreatedAt  time.Time          `json:"createdAt"`
+	StartedAt  *time.Time         `json:"startedAt,omitempty"`
+	FinishedAt *time.Time         `json:"finishedAt,omitempty"`
+}
+
+// CreateImportJob queues an uploaded file for import in the background and
+// returns the job with 202 Accepted. Poll its Location for progress. The
+// file is stored with the job, so uploads are capped like direct imports.
+func (r *Router) CreateImportJob(c *gin.Context) {
+	if !r.importsEnabled(c) {
+		return
+	}
+
+	verr := &ValidationError{}
+	header, err := r.uploadedFile(c, verr)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	if len(verr.Fields) > 0 {
+		respondError(c, verr)
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	defer file.Close()
+	payload, err := io.ReadAll(file)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	job, err := r.importer.Submit(c.Request.Context(), filepath.Base(header.Filename), actorOf(c), payload)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Header("Location", fmt.Sprintf("/v1/imports/%d", job.ID))
+	c.JSON(http.StatusAccepted, importJobResponse(job))
+}
+
+// GetImportJob reports the status and progress of an import job
+func (r *Router) GetImportJob(c *gin.Context) {
+	if !r.importsEnabled(c) {
+		return
+	}
+	id, ok := pathJobID(c)
+	if !ok {
+		return
+	}
+
+	job, err := r.importer.Job(id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, importJobResponse(job))
+}
+
+// CancelImportJob cancels a queued or running import job. Codes imported
+// before the cancellation are kept.
+func (r *Router) CancelImportJob(c *gin.Context) {
+	if !r.importsEnabled(c) {
+		return
+	}
+	id, ok := pathJobID(c)
+	if !ok {
+		return
+	}
+
+	job, err := r.importer.Cancel(auditContext(c), id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, importJobResponse(job))
+}
+
+// importsEnabled responds 503 unless the router has an importer
+func (r *Router) importsEnabled(c *gin.Context) bool {
+	if r.importer == nil {
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, ErrorResponse{
+			Error: "Import jobs are not enabled",
+		})
+		return false
+	}
+	return true
+}
+
+// pathJobID parses the id path parameter, responding 400 when it is not a
+// job ID
+func pathJobID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		verr := &ValidationError{}
+		verr.add("id", "must be a positive integer")
+		respondError(c, verr)
+		return 0, false
+	}
+	return id, true
+}
+
+func importJobResponse(job *database.ImportJob) ImportJobResponse {
+	return ImportJobResponse{
+		ID:         job.ID,
+		FileName:   job.FileName,
+		Actor:      job.Actor,
+		Status:     job.Status,
+		Attempts:   job.Attempts,
+		Imported:   job.Imported,
+		Accepted:   job.Accepted,
+		Skipped:    job.Skipped,
+		Rejected:   job.Rejected,
+		Errors:     nonNilStrings(job.Errors),
+		Error:      job.Error,
+		CreatedAt:  job.CreatedAt,
+		StartedAt:  job.StartedAt,
+		FinishedAt: job.FinishedAt,
+	}
+}
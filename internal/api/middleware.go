@@ -1,7 +1,10 @@
 package api
 
 import (
+	"crypto/subtle"
 	"log"
+	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -32,8 +35,8 @@ func ErrorHandler() gin.HandlerFunc {
 		defer func() {
 			if err := recover(); err != nil {
 				log.Printf("Panic recovered: %v", err)
-				c.JSON(500, gin.H{
-					"error": "Internal server error",
+				c.AbortWithStatusJSON(http.StatusInternalServerError, ErrorResponse{
+					Error: "Internal server error",
 				})
 			}
 		}()
@@ -41,14 +44,45 @@ func ErrorHandler() gin.HandlerFunc {
 	}
 }
 
-// ValidateSwiftCode middleware validates SWIFT code format
+// ValidateSwiftCode middleware validates the SWIFT code path parameter: a
+// full 11 character code, a BIC8, or a 4 or 6 character prefix
 func ValidateSwiftCode() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		if swiftCode := c.Param("swiftCode"); len(swiftCode) != 11 {
-			c.JSON(400, gin.H{"error": "Invalid SWIFT code format"})
-			c.Abort()
+		switch len(c.Param("swiftCode")) {
+		case 4, 6, 8, 11:
+		default:
+			c.AbortWithStatusJSON(http.StatusBadRequest, ErrorResponse{
+				Error: "Invalid SWIFT code format: must be 4, 6, 8 or 11 characters",
+			})
 			return
 		}
 		c.Next()
 	}
 }
+
+// adminKey is set in the context of requests admitted by RequireAdmin
+const adminKey = "admin"
+
+// RequireAdmin middleware only admits requests carrying token as a bearer
+// token in the Authorization header. An empty token disables the route.
+func RequireAdmin(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" {
+			c.AbortWithStatusJSON(http.StatusForbidden, ErrorResponse{
+				Error: "Admin endpoints are disabled",
+			})
+			return
+		}
+
+		bearer, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(bearer), []byte(token)) != 1 {
+			c.Header("WWW-Authenticate", "Bearer")
+			c.AbortWithStatusJSON(http.StatusUnauthorized, ErrorResponse{
+				Error: "Admin token required",
+			})
+			return
+		}
+		c.Set(adminKey, true)
+		c.Next()
+	}
+}
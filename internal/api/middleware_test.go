@@ -55,6 +55,21 @@ func TestValidateSwiftCode(t *testing.T) {
 			swiftCode:  "TESTTR00XXX",
 			wantStatus: http.StatusOK,
 		},
+		{
+			name:       "BIC8",
+			swiftCode:  "TESTTR00",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "Institution prefix",
+			swiftCode:  "TEST",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "Bank and country prefix",
+			swiftCode:  "TESTTR",
+			wantStatus: http.StatusOK,
+		},
 		{
 			name:       "Invalid length",
 			swiftCode:  "SHORT",
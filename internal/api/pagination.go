@@ -0,0 +1,109 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"swift-parser/internal/database"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// defaultPageLimit is the page size of a cursor request without limit
+	defaultPageLimit = 100
+	maxPageLimit     = 1000
+)
+
+// cursor is the decoded form of the opaque nextCursor token. It records the
+// sort order so a cursor cannot be reused with a different one.
+type cursor struct {
+	Sort          database.SortField `json:"s,omitempty"`
+	SwiftCode     string             `json:"c"`
+	BankName      string             `json:"b,omitempty"`
+	IsHeadquarter bool               `json:"h,omitempty"`
+}
+
+func encodeCursor(sort database.SortField, last database.Keyset) string {
+	data, _ := json.Marshal(cursor{
+		Sort:          sort,
+		SwiftCode:     last.SwiftCode,
+		BankName:      last.BankName,
+		IsHeadquarter: last.IsHeadquarter,
+	})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(token string, sort database.SortField) (*database.Keyset, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, errors.New("is not a valid cursor")
+	}
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil || c.SwiftCode == "" {
+		return nil, errors.New("is not a valid cursor")
+	}
+	if c.Sort != sort {
+		return nil, errors.New("was issued for a different sort order")
+	}
+	return &database.Keyset{SwiftCode: c.SwiftCode, BankName: c.BankName, IsHeadquarter: c.IsHeadquarter}, nil
+}
+
+// parseCountryQuery reads the limit, cursor, sort, isHeadquarter, bankName
+// and asOf query parameters. The returned limit is the page size; the query
+// asks for one extra row to detect whether another page follows. Without
+// limit and cursor the limit is zero and every code is listed, as before
+// pagination was introduced.
+func parseCountryQuery(c *gin.Context) (database.CountryQuery, int, error) {
+	verr := &ValidationError{}
+	q := database.CountryQuery{BankNamePrefix: c.Query("bankName")}
+
+	limit := 0
+	if c.Query("cursor") != "" {
+		limit = defaultPageLimit
+	}
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > maxPageLimit {
+			verr.add("limit", "must be an integer between 1 and %d", maxPageLimit)
+		} else {
+			limit = n
+		}
+	}
+	if limit > 0 {
+		q.Limit = limit + 1
+	}
+
+	switch sort := database.SortField(c.Query("sort")); sort {
+	case database.SortDefault, database.SortCode, database.SortBankName:
+		q.SortBy = sort
+	default:
+		verr.add("sort", "must be %q or %q", database.SortCode, database.SortBankName)
+	}
+
+	if raw := c.Query("isHeadquarter"); raw != "" {
+		isHeadquarter, err := strconv.ParseBool(raw)
+		if err != nil {
+			verr.add("isHeadquarter", "must be true or false")
+		} else {
+			q.IsHeadquarter = &isHeadquarter
+		}
+	}
+
+	q.AsOf = parseAsOf(c, verr)
+
+	if token := c.Query("cursor"); token != "" {
+		after, err := decodeCursor(token, q.SortBy)
+		if err != nil {
+			verr.add("cursor", "%v", err)
+		} else {
+			q.After = after
+		}
+	}
+
+	if len(verr.Fields) > 0 {
+		return q, 0, verr
+	}
+	return q, limit, nil
+}
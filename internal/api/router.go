@@ -2,16 +2,66 @@ package api
 
 import (
 	"swift-parser/internal/database"
+	"swift-parser/internal/importer"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+// DefaultRetention is how long a deleted SWIFT code can be restored
+const DefaultRetention = 30 * 24 * time.Hour
+
+// DefaultMaxUploadSize caps the request body of a file upload, in bytes
+const DefaultMaxUploadSize = 32 << 20
+
 type Router struct {
-	db *database.DB
+	store      database.SwiftCodeStore
+	adminToken string
+	retention  time.Duration
+	importer   *importer.Runner
+	maxUpload  int64
+}
+
+// Option configures a Router
+type Option func(*Router)
+
+// WithAdminToken enables the admin endpoints for requests bearing token.
+// Without it they are disabled.
+func WithAdminToken(token string) Option {
+	return func(r *Router) {
+		r.adminToken = token
+	}
+}
+
+// WithRetention sets how long a deleted SWIFT code can be restored. Zero
+// allows restoring until the code is purged.
+func WithRetention(retention time.Duration) Option {
+	return func(r *Router) {
+		r.retention = retention
+	}
+}
+
+// WithMaxUploadSize caps the request body of a file upload at n bytes.
+// Larger uploads are refused with 413.
+func WithMaxUploadSize(n int64) Option {
+	return func(r *Router) {
+		r.maxUpload = n
+	}
+}
+
+// WithImporter enables the import job endpoints, run by runner
+func WithImporter(runner *importer.Runner) Option {
+	return func(r *Router) {
+		r.importer = runner
+	}
 }
 
-func NewRouter(db *database.DB) *Router {
-	return &Router{db: db}
+func NewRouter(store database.SwiftCodeStore, opts ...Option) *Router {
+	r := &Router{store: store, retention: DefaultRetention, maxUpload: DefaultMaxUploadSize}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
 }
 
 func (r *Router) Setup() *gin.Engine {
@@ -19,10 +69,29 @@ func (r *Router) Setup() *gin.Engine {
 
 	v1 := router.Group("/v1/swift-codes")
 	{
-		v1.GET("/:swiftCode", r.GetSWIFTCode)
+		v1.GET("/search", r.SearchSWIFTCodes)
+		v1.GET("/export", r.ExportSWIFTCodes)
+		v1.GET("/:swiftCode", ValidateSwiftCode(), r.GetSWIFTCode)
+		v1.GET("/:swiftCode/history", r.GetSWIFTCodeHistory)
 		v1.GET("/country/:countryISO2", r.GetSWIFTCodesByCountry)
 		v1.POST("", r.PostSWIFTCode)
+		v1.POST("/import", RequireAdmin(r.adminToken), r.ImportSWIFTCodes)
+		v1.PUT("/:swiftCode", r.PutSWIFTCode)
+		v1.PATCH("/:swiftCode", r.PatchSWIFTCode)
 		v1.DELETE("/:swiftCode", r.DeleteSWIFTCode)
+		v1.POST("/:swiftCode/restore", RequireAdmin(r.adminToken), r.RestoreSWIFTCode)
+	}
+
+	imports := router.Group("/v1/imports", RequireAdmin(r.adminToken))
+	{
+		imports.POST("", r.CreateImportJob)
+		imports.GET("/:id", r.GetImportJob)
+		imports.DELETE("/:id", r.CancelImportJob)
+	}
+
+	admin := router.Group("/v1/admin", RequireAdmin(r.adminToken))
+	{
+		admin.GET("/swift-codes/deleted", r.GetDeletedSWIFTCodes)
 	}
 
 	return router
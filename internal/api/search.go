@@ -0,0 +1,104 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"swift-parser/internal/database"
+	"swift-parser/pkg/validator"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+type SearchResultResponse struct {
+	Address       string  `json:"address"`
+	BankName      string  `json:"bankName"`
+	CountryISO2   string  `json:"countryISO2"`
+	CountryName   string  `json:"countryName"`
+	IsHeadquarter bool    `json:"isHeadquarter"`
+	SwiftCode     string  `json:"swiftCode"`
+	Rank          float64 `json:"rank"`
+}
+
+type SearchResponse struct {
+	Query   string                 `json:"query"`
+	Results []SearchResultResponse `json:"results"`
+}
+
+func (r *Router) SearchSWIFTCodes(c *gin.Context) {
+	q, err := parseSearchQuery(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	results, err := r.store.SearchSWIFTCodes(q)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	response := SearchResponse{
+		Query:   q.Text,
+		Results: make([]SearchResultResponse, len(results)),
+	}
+	for i, result := range results {
+		response.Results[i] = SearchResultResponse{
+			Address:       result.Address,
+			BankName:      result.BankName,
+			CountryISO2:   result.CountryISO2,
+			CountryName:   result.CountryName,
+			IsHeadquarter: result.IsHeadquarter,
+			SwiftCode:     result.SwiftCode.SwiftCode,
+			Rank:          result.Rank,
+		}
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// parseSearchQuery reads the q, country, isHeadquarter and limit query
+// parameters
+func parseSearchQuery(c *gin.Context) (database.SearchQuery, error) {
+	verr := &ValidationError{}
+	q := database.SearchQuery{
+		Text:        strings.TrimSpace(c.Query("q")),
+		CountryISO2: strings.ToUpper(c.Query("country")),
+		Limit:       defaultSearchLimit,
+	}
+
+	if q.Text == "" {
+		verr.add("q", "is required")
+	}
+
+	if q.CountryISO2 != "" && !validator.IsKnownCountry(q.CountryISO2) {
+		verr.add("country", "%q is not an ISO 3166-1 country code", q.CountryISO2)
+	}
+
+	if raw := c.Query("isHeadquarter"); raw != "" {
+		isHeadquarter, err := strconv.ParseBool(raw)
+		if err != nil {
+			verr.add("isHeadquarter", "must be true or false")
+		} else {
+			q.IsHeadquarter = &isHeadquarter
+		}
+	}
+
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > maxSearchLimit {
+			verr.add("limit", "must be an integer between 1 and %d", maxSearchLimit)
+		} else {
+			q.Limit = n
+		}
+	}
+
+	if len(verr.Fields) > 0 {
+		return q, verr
+	}
+	return q, nil
+}
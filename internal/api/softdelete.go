@@ -0,0 +1,57 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DeletedSwiftCodeResponse struct {
+	SwiftCodeValues
+	DeletedAt time.Time `json:"deletedAt"`
+	// RestorableUntil is omitted when the retention window is unlimited
+	RestorableUntil *time.Time `json:"restorableUntil,omitempty"`
+}
+
+type DeletedSwiftCodesResponse struct {
+	SwiftCodes []DeletedSwiftCodeResponse `json:"swiftCodes"`
+}
+
+// GetDeletedSWIFTCodes lists the soft-deleted SWIFT codes not yet purged,
+// most recently deleted first
+func (r *Router) GetDeletedSWIFTCodes(c *gin.Context) {
+	codes, err := r.store.GetDeletedSWIFTCodes()
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	response := DeletedSwiftCodesResponse{
+		SwiftCodes: make([]DeletedSwiftCodeResponse, len(codes)),
+	}
+	for i, code := range codes {
+		response.SwiftCodes[i] = DeletedSwiftCodeResponse{
+			SwiftCodeValues: *valuesOf(&code.SwiftCode),
+			DeletedAt:       code.DeletedAt,
+		}
+		if r.retention > 0 {
+			until := code.DeletedAt.Add(r.retention)
+			response.SwiftCodes[i].RestorableUntil = &until
+		}
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// RestoreSWIFTCode restores a soft-deleted SWIFT code deleted within the
+// retention window
+func (r *Router) RestoreSWIFTCode(c *gin.Context) {
+	restored, err := r.store.RestoreSWIFTCode(auditContext(c), pathSwiftCode(c), r.retention)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	r.setWriteETag(c, restored)
+	c.JSON(http.StatusOK, gin.H{"message": "SWIFT code restored successfully"})
+}
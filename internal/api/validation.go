@@ -0,0 +1,154 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"swift-parser/internal/database"
+	"swift-parser/internal/models"
+	"swift-parser/pkg/validator"
+)
+
+// FieldError describes why a single request field was rejected
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError lists every field that failed validation. It matches
+// database.ErrInvalidInput with errors.Is.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	parts := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		parts[i] = f.Field + ": " + f.Message
+	}
+	return "validation failed: " + strings.Join(parts, "; ")
+}
+
+func (e *ValidationError) Unwrap() error {
+	return database.ErrInvalidInput
+}
+
+func (e *ValidationError) add(field, format string, args ...any) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// swiftCodePatch is the body of a PATCH request. Nil fields are left
+// unchanged.
+type swiftCodePatch struct {
+	Address       *string `json:"address"`
+	BankName      *string `json:"bankName"`
+	CountryISO2   *string `json:"countryISO2"`
+	CountryName   *string `json:"countryName"`
+	IsHeadquarter *bool   `json:"isHeadquarter"`
+	SwiftCode     *string `json:"swiftCode"`
+}
+
+// apply copies the fields set in p onto code
+func (p swiftCodePatch) apply(code *models.SwiftCode) {
+	if p.Address != nil {
+		code.Address = *p.Address
+	}
+	if p.BankName != nil {
+		code.BankName = *p.BankName
+	}
+	if p.CountryISO2 != nil {
+		code.CountryISO2 = *p.CountryISO2
+	}
+	if p.CountryName != nil {
+		code.CountryName = *p.CountryName
+	}
+	if p.IsHeadquarter != nil {
+		code.IsHeadquarter = *p.IsHeadquarter
+	}
+	if p.SwiftCode != nil {
+		code.SwiftCode = *p.SwiftCode
+	}
+}
+
+// requireAll adds a field error to verr for every field but swiftCode that
+// is missing from p, as a full replacement must set them all
+func (p swiftCodePatch) requireAll(verr *ValidationError) {
+	missing := func(field string, set bool) {
+		if !set {
+			verr.add(field, "is required")
+		}
+	}
+	missing("countryISO2", p.CountryISO2 != nil)
+	missing("countryName", p.CountryName != nil)
+	missing("bankName", p.BankName != nil)
+	missing("address", p.Address != nil)
+	missing("isHeadquarter", p.IsHeadquarter != nil)
+}
+
+// normalizeSwiftCode trims and upper-cases all text fields and expands a
+// BIC8 to its XXX headquarter form
+func normalizeSwiftCode(code *models.SwiftCode) {
+	code.SwiftCode = strings.ToUpper(strings.TrimSpace(code.SwiftCode))
+	code.CountryISO2 = strings.ToUpper(strings.TrimSpace(code.CountryISO2))
+	code.CountryName = strings.ToUpper(strings.TrimSpace(code.CountryName))
+	code.BankName = strings.ToUpper(strings.TrimSpace(code.BankName))
+	code.Address = strings.ToUpper(strings.TrimSpace(code.Address))
+
+	if len(code.SwiftCode) == 8 {
+		code.SwiftCode += "XXX"
+	}
+}
+
+// validateSwiftCode checks a normalized SWIFT code record and returns a
+// *ValidationError listing every failing field, or nil
+func validateSwiftCode(code *models.SwiftCode) error {
+	verr := &ValidationError{}
+
+	var bic *validator.BIC
+	if code.SwiftCode == "" {
+		verr.add("swiftCode", "is required")
+	} else if parsed, err := validator.ParseBIC(code.SwiftCode); err != nil {
+		var bicErr *validator.BICError
+		if errors.As(err, &bicErr) {
+			verr.add("swiftCode", "%s: %s", bicErr.Rule, bicErr.Reason)
+		} else {
+			verr.add("swiftCode", "%v", err)
+		}
+	} else {
+		bic = &parsed
+	}
+
+	countryName, knownCountry := validator.CountryName(code.CountryISO2)
+	switch {
+	case code.CountryISO2 == "":
+		verr.add("countryISO2", "is required")
+	case !knownCountry:
+		verr.add("countryISO2", "%q is not an ISO 3166-1 country code", code.CountryISO2)
+	case bic != nil && bic.CountryCode != code.CountryISO2:
+		verr.add("countryISO2", "must match characters 5-6 of the SWIFT code (%s)", bic.CountryCode)
+	}
+
+	switch {
+	case code.CountryName == "":
+		verr.add("countryName", "is required")
+	case knownCountry && code.CountryName != countryName:
+		verr.add("countryName", "must be %q for country %s", countryName, code.CountryISO2)
+	}
+
+	if code.BankName == "" {
+		verr.add("bankName", "is required")
+	}
+
+	if bic != nil && code.IsHeadquarter != bic.IsHeadquarter() {
+		if bic.IsHeadquarter() {
+			verr.add("isHeadquarter", "must be true for a code ending in XXX")
+		} else {
+			verr.add("isHeadquarter", "must be false for a code not ending in XXX")
+		}
+	}
+
+	if len(verr.Fields) > 0 {
+		return verr
+	}
+	return nil
+}
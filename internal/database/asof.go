@@ -0,0 +1,162 @@
+package database
+
+import (
+	"fmt"
+	"swift-parser/internal/models"
+	"time"
+)
+
+// GetSWIFTCodeAsOf returns code as it was at asOf, reconstructed from the
+// change history. It returns ErrNotFound if the code did not exist then.
+// Historic records carry no version.
+func (db *DB) GetSWIFTCodeAsOf(code string, asOf time.Time) (*models.SwiftCode, error) {
+	codes, err := db.snapshotAsOf(asOf, snapshotFilter{code: code})
+	if err != nil {
+		return nil, err
+	}
+	if len(codes) == 0 {
+		return nil, fmt.Errorf("swift code %q as of %s: %w", code, asOf.Format(time.RFC3339), ErrNotFound)
+	}
+	return &codes[0], nil
+}
+
+// GetBranchesAsOf returns the branches of a headquarter SWIFT code that
+// existed at asOf, matched as GetBranches does
+func (db *DB) GetBranchesAsOf(headquarterCode string, asOf time.Time) ([]models.SwiftCode, error) {
+	if len(headquarterCode) < 6 {
+		return nil, fmt.Errorf("headquarter code %q: %w", headquarterCode, ErrInvalidInput)
+	}
+
+	codes, err := db.snapshotAsOf(asOf, snapshotFilter{codeLike: escapeLike(headquarterCode[:6]) + "%"})
+	if err != nil {
+		return nil, err
+	}
+
+	var branches []models.SwiftCode
+	for _, code := range codes {
+		if code.SwiftCode != headquarterCode && !code.IsHeadquarter {
+			branches = append(branches, code)
+		}
+	}
+	return branches, nil
+}
+
+// getSWIFTCodesByCountryAsOf lists a country as it was at q.AsOf. Only the
+// history of the country is read, using its index; the remaining filters,
+// sorting and pagination run in Go.
+func (db *DB) getSWIFTCodesByCountryAsOf(countryISO2 string, q CountryQuery) ([]models.SwiftCode, error) {
+	codes, err := db.snapshotAsOf(q.AsOf, snapshotFilter{countryISO2: countryISO2})
+	if err != nil {
+		return nil, err
+	}
+
+	codes = applyCountryQuery(codes, q)
+	if len(codes) == 0 && q.unfiltered() {
+		return nil, fmt.Errorf("swift codes for country %q as of %s: %w",
+			countryISO2, q.AsOf.Format(time.RFC3339), ErrNotFound)
+	}
+	return codes, nil
+}
+
+// snapshotFilter narrows the history read by snapshotAsOf. Empty fields
+// are ignored.
+type snapshotFilter struct {
+	// code keeps exactly this code
+	code string
+	// codeLike is a LIKE pattern, so callers escape what they match
+	codeLike    string
+	countryISO2 string
+}
+
+// snapshotAsOf returns the codes that existed at asOf, each with the values
+// of its latest change up to then, ordered by code
+func (db *DB) snapshotAsOf(asOf time.Time, f snapshotFilter) ([]models.SwiftCode, error) {
+	args := []any{asOf}
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	inner := `
+            SELECT DISTINCT ON (swift_code) swift_code, operation, new_values
+            FROM swift_code_history
+            WHERE changed_at <= $1`
+	if f.code != "" {
+		inner += ` AND swift_code = ` + arg(f.code)
+	}
+	if f.codeLike != "" {
+		inner += ` AND swift_code LIKE ` + arg(f.codeLike)
+	}
+	if f.countryISO2 != "" {
+		inner += ` AND country_iso2 = ` + arg(f.countryISO2)
+	}
+	inner += ` ORDER BY swift_code, changed_at DESC, id DESC`
+
+	query := `
+        SELECT new_values FROM (` + inner + `
+        ) latest
+        WHERE operation <> 'delete'
+        ORDER BY swift_code COLLATE "C"`
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var codes []models.SwiftCode
+	for rows.Next() {
+		var values []byte
+		if err := rows.Scan(&values); err != nil {
+			return nil, err
+		}
+		code, err := decodeSnapshot(values)
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, *code)
+	}
+	return codes, rows.Err()
+}
+
+// GetSWIFTCodeAsOf returns code as it was at asOf, reconstructed from the
+// change history. It returns ErrNotFound if the code did not exist then.
+func (m *MemoryStore) GetSWIFTCodeAsOf(code string, asOf time.Time) (*models.SwiftCode, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	swiftCode, ok := m.snapshotAsOf(asOf)[code]
+	if !ok {
+		return nil, fmt.Errorf("swift code %q as of %s: %w", code, asOf.Format(time.RFC3339), ErrNotFound)
+	}
+	return &swiftCode, nil
+}
+
+// GetBranchesAsOf returns the branches of a headquarter SWIFT code that
+// existed at asOf, matched as GetBranches does
+func (m *MemoryStore) GetBranchesAsOf(headquarterCode string, asOf time.Time) ([]models.SwiftCode, error) {
+	if len(headquarterCode) < 6 {
+		return nil, fmt.Errorf("headquarter code %q: %w", headquarterCode, ErrInvalidInput)
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return branchesOf(m.snapshotAsOf(asOf), headquarterCode), nil
+}
+
+// snapshotAsOf replays the history up to asOf. Callers must hold mu.
+func (m *MemoryStore) snapshotAsOf(asOf time.Time) map[string]models.SwiftCode {
+	codes := make(map[string]models.SwiftCode)
+	for _, entry := range m.history {
+		if entry.ChangedAt.After(asOf) {
+			continue
+		}
+		if entry.NewValues == nil {
+			delete(codes, entry.SwiftCode)
+		} else {
+			codes[entry.SwiftCode] = *entry.NewValues
+		}
+	}
+	return codes
+}
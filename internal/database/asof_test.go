@@ -0,0 +1,78 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestGetSWIFTCodeAsOf(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	ctx := context.Background()
+	codes := benchmarkCodes(2)
+	cleanupBenchmarkCodes(t, db, codes)
+	defer cleanupBenchmarkCodes(t, db, codes)
+
+	if err := db.InsertSwiftCodes(ctx, codes); err != nil {
+		t.Fatalf("Failed to insert SWIFT codes: %v", err)
+	}
+
+	// History is stamped by the database clock
+	var before time.Time
+	if err := db.QueryRow(`SELECT clock_timestamp()`).Scan(&before); err != nil {
+		t.Fatalf("Failed to read database time: %v", err)
+	}
+	time.Sleep(time.Millisecond)
+
+	renamed := codes[0]
+	renamed.BankName = "RENAMED BANK"
+	if err := db.UpdateSWIFTCode(ctx, &renamed, UpdateOptions{}); err != nil {
+		t.Fatalf("Failed to update SWIFT code: %v", err)
+	}
+	if err := db.DeleteSWIFTCode(ctx, codes[1].SwiftCode, DeleteOptions{}); err != nil {
+		t.Fatalf("Failed to delete SWIFT code: %v", err)
+	}
+	now := time.Now().Add(time.Minute)
+
+	got, err := db.GetSWIFTCodeAsOf(codes[0].SwiftCode, before)
+	if err != nil {
+		t.Fatalf("Failed to get SWIFT code as of before: %v", err)
+	}
+	if got.BankName != codes[0].BankName {
+		t.Errorf("want bank name %q before the update, got %q", codes[0].BankName, got.BankName)
+	}
+	if got, err := db.GetSWIFTCodeAsOf(codes[0].SwiftCode, now); err != nil || got.BankName != "RENAMED BANK" {
+		t.Errorf("want renamed bank now, got %+v and %v", got, err)
+	}
+
+	if _, err := db.GetSWIFTCodeAsOf(codes[1].SwiftCode, before); err != nil {
+		t.Errorf("want %s before its delete, got %v", codes[1].SwiftCode, err)
+	}
+	if _, err := db.GetSWIFTCodeAsOf(codes[1].SwiftCode, now); !errors.Is(err, ErrNotFound) {
+		t.Errorf("want ErrNotFound after the delete, got %v", err)
+	}
+
+	// LIKE wildcards in the code match nothing
+	if _, err := db.GetSWIFTCodeAsOf("ZZBNPL%____", now); !errors.Is(err, ErrNotFound) {
+		t.Errorf("want ErrNotFound for a wildcard code, got %v", err)
+	}
+
+	branches, err := db.GetBranchesAsOf("ZZBNPLPWXXX", before)
+	if err != nil {
+		t.Fatalf("Failed to get branches as of before: %v", err)
+	}
+	if len(branches) != 2 {
+		t.Errorf("want 2 branches before the delete, got %+v", branches)
+	}
+
+	listed, err := db.GetSWIFTCodesByCountry("PL", CountryQuery{AsOf: before, BankNamePrefix: "BENCH BANK"})
+	if err != nil {
+		t.Fatalf("Failed to list country as of before: %v", err)
+	}
+	if len(listed) != 2 {
+		t.Errorf("want both codes listed before the delete, got %+v", listed)
+	}
+}
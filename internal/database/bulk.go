@@ -0,0 +1,214 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"swift-parser/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// DefaultBulkBatchSize is the number of rows copied per transaction when
+// BulkOptions.BatchSize is not set
+const DefaultBulkBatchSize = 5000
+
+// BulkOptions configures BulkLoad
+type BulkOptions struct {
+	// BatchSize is the number of rows copied and merged per transaction
+	BatchSize int
+}
+
+// BulkResult counts how a bulk load affected swift_codes. Codes repeated
+// in the input are counted once, with the last occurrence winning.
+type BulkResult struct {
+	Inserted  int
+	Updated   int
+	Unchanged int
+}
+
+func (r *BulkResult) add(other BulkResult) {
+	r.Inserted += other.Inserted
+	r.Updated += other.Updated
+	r.Unchanged += other.Unchanged
+}
+
+// BulkLoad upserts codes using COPY into a temporary staging table followed
+// by a single merge statement per batch. Each batch is committed in its own
+// transaction; on error the returned result covers the committed batches.
+func (db *DB) BulkLoad(ctx context.Context, codes []models.SwiftCode, opts BulkOptions) (BulkResult, error) {
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultBulkBatchSize
+	}
+
+	var result BulkResult
+	for start := 0; start < len(codes); start += batchSize {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		end := min(start+batchSize, len(codes))
+		batchResult, err := db.bulkLoadBatch(ctx, codes[start:end])
+		if err != nil {
+			return result, fmt.Errorf("bulk load rows %d-%d: %w", start+1, end, err)
+		}
+		result.add(batchResult)
+	}
+	return result, nil
+}
+
+func (db *DB) bulkLoadBatch(ctx context.Context, codes []models.SwiftCode) (BulkResult, error) {
+	tx, err := db.beginAudited(ctx)
+	if err != nil {
+		return BulkResult{}, err
+	}
+	defer tx.Rollback()
+
+	result, err := mergeBatch(ctx, tx, codes)
+	if err != nil {
+		return BulkResult{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return BulkResult{}, err
+	}
+	return result, nil
+}
+
+// Load is a bulk load running in a single transaction. Nothing it writes
+// is visible to other sessions until Commit, and Rollback undoes all of it,
+// including a Truncate.
+type Load struct {
+	tx *sql.Tx
+}
+
+// BeginLoad starts a Load tagged with the audit carried by ctx
+func (db *DB) BeginLoad(ctx context.Context) (*Load, error) {
+	tx, err := db.beginAudited(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &Load{tx: tx}, nil
+}
+
+// Truncate removes every SWIFT code like TruncateSwiftCodes, as part of the
+// load
+func (l *Load) Truncate(ctx context.Context) error {
+	return truncateSwiftCodes(ctx, l.tx)
+}
+
+// Load upserts codes like BulkLoad, but as part of the load. The audit
+// carried by ctx applies to the changes it makes, so each input can be
+// recorded under its own source.
+func (l *Load) Load(ctx context.Context, codes []models.SwiftCode) (BulkResult, error) {
+	if err := setAudit(ctx, l.tx); err != nil {
+		return BulkResult{}, err
+	}
+	return mergeBatch(ctx, l.tx, codes)
+}
+
+// Commit makes every change of the load visible at once
+func (l *Load) Commit() error {
+	return l.tx.Commit()
+}
+
+// Rollback discards the load. It does nothing after Commit.
+func (l *Load) Rollback() error {
+	if err := l.tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+		return err
+	}
+	return nil
+}
+
+// mergeBatch copies codes into the staging table and merges them into
+// swift_codes within tx. The staging table lives until tx ends, so it is
+// emptied first when tx merges several batches.
+func mergeBatch(ctx context.Context, tx *sql.Tx, codes []models.SwiftCode) (BulkResult, error) {
+	_, err := tx.ExecContext(ctx, `
+        CREATE TEMP TABLE IF NOT EXISTS swift_codes_staging (
+            seq INTEGER NOT NULL,
+            swift_code VARCHAR(11) NOT NULL,
+            country_iso2 CHAR(2) NOT NULL,
+            country_name VARCHAR(100) NOT NULL,
+            bank_name VARCHAR(255) NOT NULL,
+            address TEXT,
+            is_headquarter BOOLEAN NOT NULL
+        ) ON COMMIT DROP`)
+	if err != nil {
+		return BulkResult{}, err
+	}
+	if _, err := tx.ExecContext(ctx, `TRUNCATE swift_codes_staging`); err != nil {
+		return BulkResult{}, err
+	}
+
+	stmt, err := tx.PrepareContext(ctx, pq.CopyIn("swift_codes_staging",
+		"seq", "swift_code", "country_iso2", "country_name",
+		"bank_name", "address", "is_headquarter"))
+	if err != nil {
+		return BulkResult{}, err
+	}
+	defer stmt.Close()
+
+	for i, code := range codes {
+		_, err = stmt.ExecContext(ctx,
+			i,
+			code.SwiftCode,
+			code.CountryISO2,
+			code.CountryName,
+			code.BankName,
+			code.Address,
+			code.IsHeadquarter,
+		)
+		if err != nil {
+			return BulkResult{}, err
+		}
+	}
+	if _, err := stmt.ExecContext(ctx); err != nil {
+		return BulkResult{}, err
+	}
+
+	// Rows whose values already match are excluded by the WHERE clause of
+	// the upsert, so they are neither inserted nor updated. Soft-deleted
+	// rows in the input are restored and counted as updated.
+	var staged int
+	var result BulkResult
+	err = tx.QueryRowContext(ctx, `
+        WITH merged AS (
+            INSERT INTO swift_codes (
+                swift_code, country_iso2, country_name,
+                bank_name, address, is_headquarter
+            )
+            SELECT DISTINCT ON (swift_code)
+                   swift_code, country_iso2, country_name,
+                   bank_name, address, is_headquarter
+            FROM swift_codes_staging
+            ORDER BY swift_code, seq DESC
+            ON CONFLICT (swift_code) DO UPDATE SET
+                country_iso2 = EXCLUDED.country_iso2,
+                country_name = EXCLUDED.country_name,
+                bank_name = EXCLUDED.bank_name,
+                address = EXCLUDED.address,
+                is_headquarter = EXCLUDED.is_headquarter,
+                deleted_at = NULL
+            WHERE (swift_codes.country_iso2, swift_codes.country_name,
+                   swift_codes.bank_name, swift_codes.address,
+                   swift_codes.is_headquarter)
+                IS DISTINCT FROM
+                  (EXCLUDED.country_iso2, EXCLUDED.country_name,
+                   EXCLUDED.bank_name, EXCLUDED.address,
+                   EXCLUDED.is_headquarter)
+               OR swift_codes.deleted_at IS NOT NULL
+            RETURNING (xmax = 0) AS inserted
+        )
+        SELECT
+            (SELECT COUNT(DISTINCT swift_code) FROM swift_codes_staging),
+            COUNT(*) FILTER (WHERE inserted),
+            COUNT(*) FILTER (WHERE NOT inserted)
+        FROM merged`).Scan(&staged, &result.Inserted, &result.Updated)
+	if err != nil {
+		return BulkResult{}, err
+	}
+	result.Unchanged = staged - result.Inserted - result.Updated
+	return result, nil
+}
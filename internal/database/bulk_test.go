@@ -0,0 +1,159 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"swift-parser/internal/models"
+	"testing"
+
+	"github.com/lib/pq"
+)
+
+// benchmarkLocations are the first characters of the generated location
+// codes. The second is always 0, which marks a test and training BIC.
+const benchmarkLocations = "ABCDEFGHIJKLMNOPQRSTUVWXYZ23456789"
+
+// benchmarkCodes generates n distinct test and training BICs of the
+// fixture institution ZZBN, which no real institution uses
+func benchmarkCodes(n int) []models.SwiftCode {
+	codes := make([]models.SwiftCode, n)
+	for i := range codes {
+		codes[i] = models.SwiftCode{
+			SwiftCode:   fmt.Sprintf("ZZBNPL%c0%03d", benchmarkLocations[i/1000], i%1000),
+			CountryISO2: "PL",
+			CountryName: "POLAND",
+			BankName:    fmt.Sprintf("BENCH BANK %d", i),
+			Address:     "BENCH ADDRESS",
+		}
+	}
+	return codes
+}
+
+// cleanupBenchmarkCodes removes exactly the given generated codes and
+// their history
+func cleanupBenchmarkCodes(t testing.TB, db *DB, codes []models.SwiftCode) {
+	names := make([]string, len(codes))
+	for i, code := range codes {
+		names[i] = code.SwiftCode
+	}
+	if _, err := db.Exec(`DELETE FROM swift_codes WHERE swift_code = ANY($1)`, pq.Array(names)); err != nil {
+		t.Fatalf("Failed to clean up: %v", err)
+	}
+	if _, err := db.Exec(`DELETE FROM swift_code_history WHERE swift_code = ANY($1)`, pq.Array(names)); err != nil {
+		t.Fatalf("Failed to clean up history: %v", err)
+	}
+}
+
+func TestBulkLoad(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	ctx := context.Background()
+	codes := benchmarkCodes(3)
+	cleanupBenchmarkCodes(t, db, codes)
+	defer cleanupBenchmarkCodes(t, db, codes)
+
+	result, err := db.BulkLoad(ctx, codes, BulkOptions{BatchSize: 2})
+	if err != nil {
+		t.Fatalf("Failed to bulk load: %v", err)
+	}
+	if want := (BulkResult{Inserted: 3}); result != want {
+		t.Errorf("first load: want %+v, got %+v", want, result)
+	}
+
+	codes[0].BankName = "RENAMED BANK"
+	codes = append(codes, codes[1])
+	result, err = db.BulkLoad(ctx, codes, BulkOptions{})
+	if err != nil {
+		t.Fatalf("Failed to bulk load: %v", err)
+	}
+	if want := (BulkResult{Updated: 1, Unchanged: 2}); result != want {
+		t.Errorf("second load: want %+v, got %+v", want, result)
+	}
+
+	got, err := db.GetSWIFTCode(codes[0].SwiftCode)
+	if err != nil {
+		t.Fatalf("Failed to get SWIFT code: %v", err)
+	}
+	if got.BankName != "RENAMED BANK" {
+		t.Errorf("want updated bank name, got %q", got.BankName)
+	}
+}
+
+func TestLoadRollback(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	ctx := context.Background()
+	codes := benchmarkCodes(2)
+	cleanupBenchmarkCodes(t, db, codes)
+	defer cleanupBenchmarkCodes(t, db, codes)
+
+	if _, err := db.BulkLoad(ctx, codes[:1], BulkOptions{}); err != nil {
+		t.Fatalf("Failed to bulk load: %v", err)
+	}
+
+	load, err := db.BeginLoad(ctx)
+	if err != nil {
+		t.Fatalf("Failed to begin load: %v", err)
+	}
+	if err := load.Truncate(ctx); err != nil {
+		t.Fatalf("Failed to truncate: %v", err)
+	}
+	for _, batch := range [][]models.SwiftCode{codes[:1], codes[1:]} {
+		if _, err := load.Load(ctx, batch); err != nil {
+			t.Fatalf("Failed to load batch: %v", err)
+		}
+	}
+	if err := load.Rollback(); err != nil {
+		t.Fatalf("Failed to roll back: %v", err)
+	}
+
+	if _, err := db.GetSWIFTCode(codes[0].SwiftCode); err != nil {
+		t.Errorf("want %s kept after rollback, got %v", codes[0].SwiftCode, err)
+	}
+	if _, err := db.GetSWIFTCode(codes[1].SwiftCode); !errors.Is(err, ErrNotFound) {
+		t.Errorf("want %s not loaded after rollback, got %v", codes[1].SwiftCode, err)
+	}
+}
+
+func BenchmarkInsertSwiftCodes(b *testing.B) {
+	db := setupTestDB(b)
+	defer db.Close()
+
+	codes := benchmarkCodes(10000)
+	ctx := context.Background()
+	defer cleanupBenchmarkCodes(b, db, codes)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		cleanupBenchmarkCodes(b, db, codes)
+		b.StartTimer()
+
+		if err := db.InsertSwiftCodes(ctx, codes); err != nil {
+			b.Fatalf("Failed to insert SWIFT codes: %v", err)
+		}
+	}
+}
+
+func BenchmarkBulkLoad(b *testing.B) {
+	db := setupTestDB(b)
+	defer db.Close()
+
+	codes := benchmarkCodes(10000)
+	ctx := context.Background()
+	defer cleanupBenchmarkCodes(b, db, codes)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		cleanupBenchmarkCodes(b, db, codes)
+		b.StartTimer()
+
+		if _, err := db.BulkLoad(ctx, codes, BulkOptions{}); err != nil {
+			b.Fatalf("Failed to bulk load SWIFT codes: %v", err)
+		}
+	}
+}
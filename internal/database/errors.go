@@ -0,0 +1,73 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// Errors returned by SwiftCodeStore implementations. They are wrapped with
+// context, so callers should check them with errors.Is.
+var (
+	ErrNotFound      = errors.New("not found")
+	ErrAlreadyExists = errors.New("already exists")
+	ErrInvalidInput  = errors.New("invalid input")
+	// ErrVersionMismatch is returned when a conditional write expected a
+	// different version of the record
+	ErrVersionMismatch = errors.New("version mismatch")
+	// ErrRetentionExpired is returned when restoring a record deleted
+	// longer ago than the retention window
+	ErrRetentionExpired = errors.New("retention window expired")
+	// ErrHasBranches is returned when deleting a headquarter that still has
+	// branches under the reject policy
+	ErrHasBranches = errors.New("headquarter has branches")
+	// ErrJobFinished is returned when canceling an import job that already
+	// finished
+	ErrJobFinished = errors.New("job already finished")
+	// ErrSyncThreshold is returned when a sync would remove more codes than
+	// allowed
+	ErrSyncThreshold = errors.New("sync removal threshold exceeded")
+)
+
+// BranchesExistError lists the branches that prevented deleting a
+// headquarter. It matches ErrHasBranches with errors.Is.
+type BranchesExistError struct {
+	SwiftCode string
+	Branches  []string
+}
+
+func (e *BranchesExistError) Error() string {
+	return fmt.Sprintf("swift code %q has %d branches: %s",
+		e.SwiftCode, len(e.Branches), strings.Join(e.Branches, ", "))
+}
+
+func (e *BranchesExistError) Unwrap() error {
+	return ErrHasBranches
+}
+
+// SyncThresholdError reports a sync refused because it would remove too
+// many codes. It matches ErrSyncThreshold with errors.Is.
+type SyncThresholdError struct {
+	Removed    int
+	Total      int
+	MaxPercent float64
+}
+
+func (e *SyncThresholdError) Error() string {
+	return fmt.Sprintf("sync would remove %d of %d codes (%.1f%%), more than the allowed %g%%",
+		e.Removed, e.Total, removedPercent(e.Removed, e.Total), e.MaxPercent)
+}
+
+func (e *SyncThresholdError) Unwrap() error {
+	return ErrSyncThreshold
+}
+
+// pgUniqueViolation is the PostgreSQL error code for unique_violation
+const pgUniqueViolation = "23505"
+
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == pgUniqueViolation
+}
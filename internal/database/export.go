@@ -0,0 +1,111 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"swift-parser/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// ExportQuery filters the codes passed to ExportSWIFTCodes. The zero value
+// exports every code.
+type ExportQuery struct {
+	// Countries keeps codes of these ISO2 countries when set
+	Countries []string
+	// IsHeadquarter keeps only headquarters or only branches when set
+	IsHeadquarter *bool
+}
+
+// matches reports whether code passes the filters of q
+func (q ExportQuery) matches(code models.SwiftCode) bool {
+	if q.IsHeadquarter != nil && code.IsHeadquarter != *q.IsHeadquarter {
+		return false
+	}
+	if len(q.Countries) == 0 {
+		return true
+	}
+	for _, country := range q.Countries {
+		if code.CountryISO2 == country {
+			return true
+		}
+	}
+	return false
+}
+
+// ExportSWIFTCodes calls fn for every code matching q, ordered by code.
+// Rows are read from the database as fn consumes them, so the table is
+// never held in memory. It stops at the first error returned by fn.
+func (db *DB) ExportSWIFTCodes(ctx context.Context, q ExportQuery, fn func(models.SwiftCode) error) error {
+	query := `
+        SELECT swift_code, country_iso2, country_name,
+               bank_name, address, is_headquarter, version
+        FROM swift_codes
+        WHERE deleted_at IS NULL`
+	var args []any
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if len(q.Countries) > 0 {
+		query += ` AND country_iso2 = ANY(` + arg(pq.Array(q.Countries)) + `)`
+	}
+	if q.IsHeadquarter != nil {
+		query += ` AND is_headquarter = ` + arg(*q.IsHeadquarter)
+	}
+	query += ` ORDER BY swift_code COLLATE "C"`
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var code models.SwiftCode
+		err := rows.Scan(
+			&code.SwiftCode,
+			&code.CountryISO2,
+			&code.CountryName,
+			&code.BankName,
+			&code.Address,
+			&code.IsHeadquarter,
+			&code.Version,
+		)
+		if err != nil {
+			return err
+		}
+		if err := fn(code); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// ExportSWIFTCodes calls fn for every code matching q, ordered by code.
+// The matching codes are copied first so fn runs without holding the lock.
+func (m *MemoryStore) ExportSWIFTCodes(ctx context.Context, q ExportQuery, fn func(models.SwiftCode) error) error {
+	m.mu.RLock()
+	var codes []models.SwiftCode
+	for _, code := range m.codes {
+		if q.matches(code) {
+			codes = append(codes, code)
+		}
+	}
+	m.mu.RUnlock()
+
+	sort.Slice(codes, func(i, j int) bool {
+		return codes[i].SwiftCode < codes[j].SwiftCode
+	})
+	for _, code := range codes {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(code); err != nil {
+			return err
+		}
+	}
+	return nil
+}
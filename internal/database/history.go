@@ -0,0 +1,204 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"swift-parser/internal/models"
+	"time"
+)
+
+// Sources recorded in the change history
+const (
+	SourceAPI = "api"
+	SourceCLI = "cli"
+	// SourceScheduler marks changes made by background jobs of the server
+	SourceScheduler = "scheduler"
+)
+
+// ImportSource returns the history source recorded for rows loaded from file
+func ImportSource(file string) string {
+	return "import:" + file
+}
+
+// Audit identifies who made a change and through which channel. It is
+// recorded with every history entry written under a context carrying it.
+type Audit struct {
+	Actor  string
+	Source string
+}
+
+type auditKey struct{}
+
+// WithAudit returns a copy of ctx carrying audit
+func WithAudit(ctx context.Context, audit Audit) context.Context {
+	return context.WithValue(ctx, auditKey{}, audit)
+}
+
+// AuditFrom returns the audit carried by ctx, or the zero Audit
+func AuditFrom(ctx context.Context) Audit {
+	audit, _ := ctx.Value(auditKey{}).(Audit)
+	return audit
+}
+
+// Operation is the kind of change recorded in a history entry
+type Operation string
+
+const (
+	OperationInsert Operation = "insert"
+	OperationUpdate Operation = "update"
+	OperationDelete Operation = "delete"
+	// OperationRestore records a soft-deleted code being restored
+	OperationRestore Operation = "restore"
+)
+
+// HistoryEntry is one recorded change to a SWIFT code. OldValues is nil
+// for inserts and restores, and NewValues is nil for deletes.
+type HistoryEntry struct {
+	ID        int64
+	SwiftCode string
+	Operation Operation
+	OldValues *models.SwiftCode
+	NewValues *models.SwiftCode
+	ChangedAt time.Time
+	Actor     string
+	Source    string
+}
+
+// beginAudited starts a transaction tagged with the audit carried by ctx,
+// which the history trigger reads from the swift.actor and swift.source
+// settings
+func (db *DB) beginAudited(ctx context.Context) (*sql.Tx, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := setAudit(ctx, tx); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	return tx, nil
+}
+
+// setAudit tags the rest of tx with the audit carried by ctx
+func setAudit(ctx context.Context, tx *sql.Tx) error {
+	audit := AuditFrom(ctx)
+	_, err := tx.ExecContext(ctx,
+		`SELECT set_config('swift.actor', $1, true), set_config('swift.source', $2, true)`,
+		audit.Actor, audit.Source)
+	return err
+}
+
+// GetHistory returns every recorded change to code, oldest first. It
+// returns ErrNotFound when code has no history.
+func (db *DB) GetHistory(code string) ([]HistoryEntry, error) {
+	query := `
+        SELECT id, swift_code, operation, old_values, new_values,
+               changed_at, COALESCE(actor, ''), COALESCE(source, '')
+        FROM swift_code_history
+        WHERE swift_code = $1
+        ORDER BY changed_at, id`
+
+	rows, err := db.Query(query, code)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []HistoryEntry
+	for rows.Next() {
+		var entry HistoryEntry
+		var oldValues, newValues []byte
+		err := rows.Scan(
+			&entry.ID,
+			&entry.SwiftCode,
+			&entry.Operation,
+			&oldValues,
+			&newValues,
+			&entry.ChangedAt,
+			&entry.Actor,
+			&entry.Source,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if entry.OldValues, err = decodeSnapshot(oldValues); err != nil {
+			return nil, err
+		}
+		if entry.NewValues, err = decodeSnapshot(newValues); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("history of swift code %q: %w", code, ErrNotFound)
+	}
+	return entries, nil
+}
+
+// decodeSnapshot decodes a JSONB snapshot written by the history trigger
+func decodeSnapshot(data []byte) (*models.SwiftCode, error) {
+	if data == nil {
+		return nil, nil
+	}
+	var code models.SwiftCode
+	if err := json.Unmarshal(data, &code); err != nil {
+		return nil, fmt.Errorf("invalid history snapshot: %w", err)
+	}
+	return &code, nil
+}
+
+// GetHistory returns every recorded change to code, oldest first. It
+// returns ErrNotFound when code has no history.
+func (m *MemoryStore) GetHistory(code string) ([]HistoryEntry, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var entries []HistoryEntry
+	for _, entry := range m.history {
+		if entry.SwiftCode == code {
+			entries = append(entries, entry)
+		}
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("history of swift code %q: %w", code, ErrNotFound)
+	}
+	return entries, nil
+}
+
+// record appends a history entry for a change from old to new, either of
+// which may be nil. Callers must hold mu.
+func (m *MemoryStore) record(ctx context.Context, op Operation, old, new *models.SwiftCode) {
+	entry := HistoryEntry{
+		ID:        int64(len(m.history) + 1),
+		Operation: op,
+		OldValues: snapshot(old),
+		NewValues: snapshot(new),
+		ChangedAt: m.now(),
+	}
+	if new != nil {
+		entry.SwiftCode = new.SwiftCode
+	} else {
+		entry.SwiftCode = old.SwiftCode
+	}
+
+	audit := AuditFrom(ctx)
+	entry.Actor, entry.Source = audit.Actor, audit.Source
+	m.history = append(m.history, entry)
+}
+
+// snapshot copies the recorded values of code, dropping its version as the
+// trigger does
+func snapshot(code *models.SwiftCode) *models.SwiftCode {
+	if code == nil {
+		return nil
+	}
+	values := *code
+	values.Version = 0
+	return &values
+}
@@ -0,0 +1,73 @@
+package database
+
+import (
+	"context"
+	"swift-parser/internal/models"
+	"testing"
+)
+
+func TestGetHistory(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	ctx := WithAudit(context.Background(), Audit{Actor: "alice", Source: SourceAPI})
+	codes := benchmarkCodes(1)
+	cleanupBenchmarkCodes(t, db, codes)
+	defer cleanupBenchmarkCodes(t, db, codes)
+
+	code := codes[0]
+	if err := db.AddSWIFTCode(ctx, &code); err != nil {
+		t.Fatalf("Failed to add SWIFT code: %v", err)
+	}
+	code.Address = "NEW ADDRESS"
+	if err := db.UpdateSWIFTCode(ctx, &code, UpdateOptions{}); err != nil {
+		t.Fatalf("Failed to update SWIFT code: %v", err)
+	}
+	// Loading the same values again changes nothing and records nothing
+	loadCtx := WithAudit(context.Background(), Audit{Actor: "bob", Source: ImportSource("codes.csv")})
+	if _, err := db.BulkLoad(loadCtx, []models.SwiftCode{code}, BulkOptions{}); err != nil {
+		t.Fatalf("Failed to bulk load: %v", err)
+	}
+	if err := db.DeleteSWIFTCode(loadCtx, code.SwiftCode, DeleteOptions{}); err != nil {
+		t.Fatalf("Failed to delete SWIFT code: %v", err)
+	}
+
+	history, err := db.GetHistory(code.SwiftCode)
+	if err != nil {
+		t.Fatalf("Failed to get history: %v", err)
+	}
+	want := []Operation{OperationInsert, OperationUpdate, OperationDelete}
+	if len(history) != len(want) {
+		t.Fatalf("want %d history entries, got %d", len(want), len(history))
+	}
+	for i, op := range want {
+		if history[i].Operation != op {
+			t.Errorf("want %s at position %d, got %s", op, i, history[i].Operation)
+		}
+	}
+
+	insert, update, deletion := history[0], history[1], history[2]
+	if insert.OldValues != nil || insert.NewValues == nil || insert.NewValues.BankName != code.BankName {
+		t.Errorf("want insert with only new values, got %+v", insert)
+	}
+	if update.OldValues.Address != "BENCH ADDRESS" || update.NewValues.Address != "NEW ADDRESS" {
+		t.Errorf("want address change, got %+v", update)
+	}
+	if update.Actor != "alice" || update.Source != SourceAPI {
+		t.Errorf("want update by alice from api, got %q from %q", update.Actor, update.Source)
+	}
+	if deletion.NewValues != nil || deletion.Actor != "bob" || deletion.Source != "import:codes.csv" {
+		t.Errorf("want delete by bob from import:codes.csv, got %+v", deletion)
+	}
+
+	var countries int
+	err = db.QueryRow(`
+        SELECT COUNT(*) FROM swift_code_history
+        WHERE swift_code = $1 AND country_iso2 = $2`, code.SwiftCode, code.CountryISO2).Scan(&countries)
+	if err != nil {
+		t.Fatalf("Failed to count history by country: %v", err)
+	}
+	if countries != len(want) {
+		t.Errorf("want every entry indexed under %s, got %d", code.CountryISO2, countries)
+	}
+}
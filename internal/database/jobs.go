@@ -0,0 +1,442 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// JobStatus is the lifecycle state of an import job
+type JobStatus string
+
+const (
+	JobQueued    JobStatus = "queued"
+	JobRunning   JobStatus = "running"
+	JobSucceeded JobStatus = "succeeded"
+	JobFailed    JobStatus = "failed"
+	JobCanceled  JobStatus = "canceled"
+)
+
+// Finished reports whether s is a terminal status
+func (s JobStatus) Finished() bool {
+	return s == JobSucceeded || s == JobFailed || s == JobCanceled
+}
+
+// ImportJob is a file import processed in the background. Imported counts
+// the codes written so far; Accepted, Skipped and Rejected are the row
+// counts of the parse, set when the job finishes.
+type ImportJob struct {
+	ID         int64
+	FileName   string
+	Actor      string
+	Status     JobStatus
+	Attempts   int
+	Imported   int
+	Accepted   int
+	Skipped    int
+	Rejected   int
+	Errors     []string
+	Error      string
+	CreatedAt  time.Time
+	StartedAt  *time.Time
+	FinishedAt *time.Time
+	// ClaimedBy identifies the runner processing the job and UpdatedAt is
+	// its last heartbeat, while the job is running
+	ClaimedBy string
+	UpdatedAt *time.Time
+}
+
+// ImportJobStore persists import jobs and the files they import, so a job
+// survives a server restart. It is implemented by DB and MemoryStore.
+type ImportJobStore interface {
+	// CreateImportJob queues job with the uploaded payload and sets its ID,
+	// status and creation time
+	CreateImportJob(ctx context.Context, job *ImportJob, payload []byte) error
+	GetImportJob(id int64) (*ImportJob, error)
+	// ClaimImportJob marks the oldest queued job running on behalf of owner
+	// and returns it with its payload. It returns ErrNotFound when no job is
+	// queued.
+	ClaimImportJob(ctx context.Context, owner string) (*ImportJob, []byte, error)
+	// UpdateImportJobProgress records how many codes a running job has
+	// imported, never lowering the count, and renews its heartbeat. It
+	// returns ErrNotFound once the job is no longer running, for example
+	// because it was canceled.
+	UpdateImportJobProgress(ctx context.Context, id int64, imported int) error
+	// FinishImportJob stores the outcome of a running job and drops its
+	// payload. It returns ErrNotFound if the job is no longer running.
+	FinishImportJob(ctx context.Context, job *ImportJob) error
+	// CancelImportJob cancels a queued or running job and returns it. It
+	// returns ErrJobFinished if the job already finished.
+	CancelImportJob(ctx context.Context, id int64) (*ImportJob, error)
+	// RecoverImportJobs requeues the running jobs whose heartbeat is older
+	// than staleAfter, as their server stopped, failing those already
+	// attempted maxAttempts times. Jobs of live servers are left alone.
+	RecoverImportJobs(ctx context.Context, maxAttempts int, staleAfter time.Duration) (requeued, failed int, err error)
+}
+
+// errInterrupted is recorded on jobs that could not be resumed after a
+// restart
+const errInterrupted = "interrupted by a server restart too many times"
+
+const importJobColumns = `
+        id, file_name, COALESCE(actor, ''), status, attempts, imported,
+        accepted, skipped, rejected, errors, COALESCE(error, ''),
+        created_at, started_at, finished_at, COALESCE(claimed_by, ''), updated_at`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanImportJob scans importJobColumns, followed by extra destinations
+func scanImportJob(row rowScanner, extra ...any) (*ImportJob, error) {
+	var job ImportJob
+	var errs []byte
+	var startedAt, finishedAt, updatedAt sql.NullTime
+	dest := append([]any{
+		&job.ID,
+		&job.FileName,
+		&job.Actor,
+		&job.Status,
+		&job.Attempts,
+		&job.Imported,
+		&job.Accepted,
+		&job.Skipped,
+		&job.Rejected,
+		&errs,
+		&job.Error,
+		&job.CreatedAt,
+		&startedAt,
+		&finishedAt,
+		&job.ClaimedBy,
+		&updatedAt,
+	}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(errs, &job.Errors); err != nil {
+		return nil, fmt.Errorf("invalid import job errors: %w", err)
+	}
+	if startedAt.Valid {
+		job.StartedAt = &startedAt.Time
+	}
+	if finishedAt.Valid {
+		job.FinishedAt = &finishedAt.Time
+	}
+	if updatedAt.Valid {
+		job.UpdatedAt = &updatedAt.Time
+	}
+	return &job, nil
+}
+
+func (db *DB) CreateImportJob(ctx context.Context, job *ImportJob, payload []byte) error {
+	query := `
+        INSERT INTO import_jobs (file_name, actor, payload)
+        VALUES ($1, NULLIF($2, ''), $3)
+        RETURNING id, status, created_at`
+
+	return db.QueryRowContext(ctx, query, job.FileName, job.Actor, payload).
+		Scan(&job.ID, &job.Status, &job.CreatedAt)
+}
+
+func (db *DB) GetImportJob(id int64) (*ImportJob, error) {
+	row := db.QueryRow(`SELECT `+importJobColumns+` FROM import_jobs WHERE id = $1`, id)
+	job, err := scanImportJob(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("import job %d: %w", id, ErrNotFound)
+	}
+	return job, err
+}
+
+func (db *DB) ClaimImportJob(ctx context.Context, owner string) (*ImportJob, []byte, error) {
+	query := `
+        UPDATE import_jobs SET
+            status = 'running',
+            attempts = attempts + 1,
+            imported = 0,
+            claimed_by = $1,
+            started_at = CURRENT_TIMESTAMP,
+            updated_at = CURRENT_TIMESTAMP
+        WHERE id = (
+            SELECT id FROM import_jobs
+            WHERE status = 'queued'
+            ORDER BY id
+            LIMIT 1
+            FOR UPDATE SKIP LOCKED
+        )
+        RETURNING` + importJobColumns + `, payload`
+
+	var payload []byte
+	job, err := scanImportJob(db.QueryRowContext(ctx, query, owner), &payload)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil, fmt.Errorf("queued import job: %w", ErrNotFound)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return job, payload, nil
+}
+
+func (db *DB) UpdateImportJobProgress(ctx context.Context, id int64, imported int) error {
+	result, err := db.ExecContext(ctx,
+		`UPDATE import_jobs SET imported = GREATEST(imported, $2), updated_at = CURRENT_TIMESTAMP
+        WHERE id = $1 AND status = 'running'`, id, imported)
+	if err != nil {
+		return err
+	}
+	return expectRow(result, fmt.Sprintf("running import job %d", id))
+}
+
+func (db *DB) FinishImportJob(ctx context.Context, job *ImportJob) error {
+	errs, err := json.Marshal(nonNil(job.Errors))
+	if err != nil {
+		return err
+	}
+
+	query := `
+        UPDATE import_jobs SET
+            status = $2,
+            imported = $3,
+            accepted = $4,
+            skipped = $5,
+            rejected = $6,
+            errors = $7,
+            error = NULLIF($8, ''),
+            payload = NULL,
+            finished_at = CURRENT_TIMESTAMP
+        WHERE id = $1 AND status = 'running'`
+
+	result, err := db.ExecContext(ctx, query,
+		job.ID,
+		job.Status,
+		job.Imported,
+		job.Accepted,
+		job.Skipped,
+		job.Rejected,
+		errs,
+		job.Error,
+	)
+	if err != nil {
+		return err
+	}
+	return expectRow(result, fmt.Sprintf("running import job %d", job.ID))
+}
+
+func (db *DB) CancelImportJob(ctx context.Context, id int64) (*ImportJob, error) {
+	query := `
+        UPDATE import_jobs SET
+            status = 'canceled',
+            payload = NULL,
+            finished_at = CURRENT_TIMESTAMP
+        WHERE id = $1 AND status IN ('queued', 'running')
+        RETURNING` + importJobColumns
+
+	job, err := scanImportJob(db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		if _, err := db.GetImportJob(id); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("import job %d: %w", id, ErrJobFinished)
+	}
+	return job, err
+}
+
+func (db *DB) RecoverImportJobs(ctx context.Context, maxAttempts int, staleAfter time.Duration) (requeued, failed int, err error) {
+	query := `
+        UPDATE import_jobs SET
+            status = CASE WHEN attempts < $1 THEN 'queued' ELSE 'failed' END,
+            error = CASE WHEN attempts < $1 THEN NULL ELSE $2 END,
+            payload = CASE WHEN attempts < $1 THEN payload END,
+            claimed_by = NULL,
+            finished_at = CASE WHEN attempts < $1 THEN NULL ELSE CURRENT_TIMESTAMP END
+        WHERE status = 'running'
+        AND COALESCE(updated_at, started_at) <= CURRENT_TIMESTAMP - make_interval(secs => $3)
+        RETURNING status`
+
+	rows, err := db.QueryContext(ctx, query, maxAttempts, errInterrupted, staleAfter.Seconds())
+	if err != nil {
+		return 0, 0, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var status JobStatus
+		if err := rows.Scan(&status); err != nil {
+			return 0, 0, err
+		}
+		if status == JobQueued {
+			requeued++
+		} else {
+			failed++
+		}
+	}
+	return requeued, failed, rows.Err()
+}
+
+// expectRow returns ErrNotFound, described by what, unless result affected
+// a row
+func expectRow(result sql.Result, what string) error {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("%s: %w", what, ErrNotFound)
+	}
+	return nil
+}
+
+// nonNil returns s, or an empty slice if s is nil, so it encodes as a JSON
+// array
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
+
+// memoryJob is an import job held by a MemoryStore with its payload
+type memoryJob struct {
+	ImportJob
+	payload []byte
+}
+
+func (m *MemoryStore) CreateImportJob(ctx context.Context, job *ImportJob, payload []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.lastJobID++
+	job.ID = m.lastJobID
+	job.Status = JobQueued
+	job.CreatedAt = m.now()
+	m.jobs[job.ID] = &memoryJob{ImportJob: *job, payload: payload}
+	return nil
+}
+
+func (m *MemoryStore) GetImportJob(id int64) (*ImportJob, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	stored, ok := m.jobs[id]
+	if !ok {
+		return nil, fmt.Errorf("import job %d: %w", id, ErrNotFound)
+	}
+	job := stored.ImportJob
+	return &job, nil
+}
+
+func (m *MemoryStore) ClaimImportJob(ctx context.Context, owner string) (*ImportJob, []byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var next *memoryJob
+	for _, stored := range m.jobs {
+		if stored.Status == JobQueued && (next == nil || stored.ID < next.ID) {
+			next = stored
+		}
+	}
+	if next == nil {
+		return nil, nil, fmt.Errorf("queued import job: %w", ErrNotFound)
+	}
+
+	now := m.now()
+	next.Status = JobRunning
+	next.Attempts++
+	next.Imported = 0
+	next.ClaimedBy = owner
+	next.StartedAt = &now
+	next.UpdatedAt = &now
+	job := next.ImportJob
+	return &job, next.payload, nil
+}
+
+func (m *MemoryStore) UpdateImportJobProgress(ctx context.Context, id int64, imported int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, err := m.runningJob(id)
+	if err != nil {
+		return err
+	}
+	now := m.now()
+	stored.Imported = max(stored.Imported, imported)
+	stored.UpdatedAt = &now
+	return nil
+}
+
+func (m *MemoryStore) FinishImportJob(ctx context.Context, job *ImportJob) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, err := m.runningJob(job.ID)
+	if err != nil {
+		return err
+	}
+	now := m.now()
+	stored.Status = job.Status
+	stored.Imported = job.Imported
+	stored.Accepted = job.Accepted
+	stored.Skipped = job.Skipped
+	stored.Rejected = job.Rejected
+	stored.Errors = nonNil(job.Errors)
+	stored.Error = job.Error
+	stored.FinishedAt = &now
+	stored.payload = nil
+	return nil
+}
+
+// runningJob returns the stored job id if it is running. Callers must hold
+// mu.
+func (m *MemoryStore) runningJob(id int64) (*memoryJob, error) {
+	stored, ok := m.jobs[id]
+	if !ok || stored.Status != JobRunning {
+		return nil, fmt.Errorf("running import job %d: %w", id, ErrNotFound)
+	}
+	return stored, nil
+}
+
+func (m *MemoryStore) CancelImportJob(ctx context.Context, id int64) (*ImportJob, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, ok := m.jobs[id]
+	if !ok {
+		return nil, fmt.Errorf("import job %d: %w", id, ErrNotFound)
+	}
+	if stored.Status.Finished() {
+		return nil, fmt.Errorf("import job %d: %w", id, ErrJobFinished)
+	}
+	now := m.now()
+	stored.Status = JobCanceled
+	stored.FinishedAt = &now
+	stored.payload = nil
+	job := stored.ImportJob
+	return &job, nil
+}
+
+func (m *MemoryStore) RecoverImportJobs(ctx context.Context, maxAttempts int, staleAfter time.Duration) (requeued, failed int, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cutoff := m.now().Add(-staleAfter)
+	for _, stored := range m.jobs {
+		if stored.Status != JobRunning || stored.UpdatedAt.After(cutoff) {
+			continue
+		}
+		stored.ClaimedBy = ""
+		if stored.Attempts < maxAttempts {
+			stored.Status = JobQueued
+			requeued++
+			continue
+		}
+		now := m.now()
+		stored.Status = JobFailed
+		stored.Error = errInterrupted
+		stored.FinishedAt = &now
+		stored.payload = nil
+		failed++
+	}
+	return requeued, failed, nil
+}
@@ -0,0 +1,159 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// testJobFile names the jobs created by these tests, so they can be removed
+const testJobFile = "jobs_test.csv"
+
+func cleanupImportJobs(t testing.TB, db *DB) {
+	if _, err := db.Exec(`DELETE FROM import_jobs WHERE file_name = $1`, testJobFile); err != nil {
+		t.Fatalf("Failed to clean up import jobs: %v", err)
+	}
+}
+
+// backdateHeartbeat makes the heartbeat of job older than any staleAfter
+// used below
+func backdateHeartbeat(t *testing.T, db *DB, id int64) {
+	t.Helper()
+	if _, err := db.Exec(`UPDATE import_jobs SET updated_at = updated_at - interval '2 hours' WHERE id = $1`, id); err != nil {
+		t.Fatalf("Failed to backdate heartbeat: %v", err)
+	}
+}
+
+func TestImportJobLease(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	ctx := context.Background()
+	cleanupImportJobs(t, db)
+	defer cleanupImportJobs(t, db)
+
+	job := &ImportJob{FileName: testJobFile, Actor: "alice"}
+	if err := db.CreateImportJob(ctx, job, []byte("payload")); err != nil {
+		t.Fatalf("Failed to create import job: %v", err)
+	}
+	if job.ID == 0 || job.Status != JobQueued {
+		t.Fatalf("want a queued job with an ID, got %+v", job)
+	}
+
+	claimed, payload, err := db.ClaimImportJob(ctx, "runner-a")
+	if err != nil {
+		t.Fatalf("Failed to claim import job: %v", err)
+	}
+	if claimed.ID != job.ID || string(payload) != "payload" {
+		t.Fatalf("want job %d with its payload, got %+v and %q", job.ID, claimed, payload)
+	}
+	if claimed.Status != JobRunning || claimed.ClaimedBy != "runner-a" || claimed.Attempts != 1 || claimed.UpdatedAt == nil {
+		t.Errorf("want job running for runner-a on its first attempt, got %+v", claimed)
+	}
+	if _, _, err := db.ClaimImportJob(ctx, "runner-b"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("want ErrNotFound with no job queued, got %v", err)
+	}
+
+	if err := db.UpdateImportJobProgress(ctx, job.ID, 5); err != nil {
+		t.Fatalf("Failed to update progress: %v", err)
+	}
+	if err := db.UpdateImportJobProgress(ctx, job.ID, 3); err != nil {
+		t.Fatalf("Failed to update progress: %v", err)
+	}
+	if got, _ := db.GetImportJob(job.ID); got == nil || got.Imported != 5 {
+		t.Errorf("want progress never lowered from 5, got %+v", got)
+	}
+
+	// A live heartbeat keeps the job with its runner
+	if requeued, failed, err := db.RecoverImportJobs(ctx, 2, time.Hour); err != nil || requeued+failed != 0 {
+		t.Fatalf("want nothing recovered, got %d requeued, %d failed and %v", requeued, failed, err)
+	}
+
+	backdateHeartbeat(t, db, job.ID)
+	if requeued, _, err := db.RecoverImportJobs(ctx, 2, time.Hour); err != nil || requeued != 1 {
+		t.Fatalf("want the stale job requeued, got %d and %v", requeued, err)
+	}
+	claimed, payload, err = db.ClaimImportJob(ctx, "runner-b")
+	if err != nil {
+		t.Fatalf("Failed to claim requeued job: %v", err)
+	}
+	if claimed.ID != job.ID || claimed.Attempts != 2 || claimed.ClaimedBy != "runner-b" || string(payload) != "payload" {
+		t.Errorf("want job %d on its second attempt with its payload, got %+v", job.ID, claimed)
+	}
+
+	backdateHeartbeat(t, db, job.ID)
+	if _, failed, err := db.RecoverImportJobs(ctx, 2, time.Hour); err != nil || failed != 1 {
+		t.Fatalf("want the job failed after 2 attempts, got %d and %v", failed, err)
+	}
+	got, err := db.GetImportJob(job.ID)
+	if err != nil {
+		t.Fatalf("Failed to get import job: %v", err)
+	}
+	if got.Status != JobFailed || got.Error != errInterrupted || got.FinishedAt == nil {
+		t.Errorf("want job failed as interrupted, got %+v", got)
+	}
+	if err := db.UpdateImportJobProgress(ctx, job.ID, 6); !errors.Is(err, ErrNotFound) {
+		t.Errorf("want ErrNotFound updating a failed job, got %v", err)
+	}
+}
+
+func TestFinishAndCancelImportJob(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	ctx := context.Background()
+	cleanupImportJobs(t, db)
+	defer cleanupImportJobs(t, db)
+
+	finished := &ImportJob{FileName: testJobFile}
+	if err := db.CreateImportJob(ctx, finished, []byte("payload")); err != nil {
+		t.Fatalf("Failed to create import job: %v", err)
+	}
+	claimed, _, err := db.ClaimImportJob(ctx, "runner-a")
+	if err != nil {
+		t.Fatalf("Failed to claim import job: %v", err)
+	}
+	claimed.Status = JobSucceeded
+	claimed.Imported, claimed.Accepted, claimed.Skipped, claimed.Rejected = 2, 2, 1, 1
+	claimed.Errors = []string{"row 3: invalid country"}
+	if err := db.FinishImportJob(ctx, claimed); err != nil {
+		t.Fatalf("Failed to finish import job: %v", err)
+	}
+
+	got, err := db.GetImportJob(finished.ID)
+	if err != nil {
+		t.Fatalf("Failed to get import job: %v", err)
+	}
+	if got.Status != JobSucceeded || got.Accepted != 2 || got.Rejected != 1 || len(got.Errors) != 1 || got.FinishedAt == nil {
+		t.Errorf("want the stored outcome, got %+v", got)
+	}
+	var hasPayload bool
+	if err := db.QueryRow(`SELECT payload IS NOT NULL FROM import_jobs WHERE id = $1`, finished.ID).Scan(&hasPayload); err != nil {
+		t.Fatalf("Failed to read payload: %v", err)
+	}
+	if hasPayload {
+		t.Error("want payload dropped once the job finished")
+	}
+	if err := db.FinishImportJob(ctx, claimed); !errors.Is(err, ErrNotFound) {
+		t.Errorf("want ErrNotFound finishing a job twice, got %v", err)
+	}
+	if _, err := db.CancelImportJob(ctx, finished.ID); !errors.Is(err, ErrJobFinished) {
+		t.Errorf("want ErrJobFinished canceling a finished job, got %v", err)
+	}
+
+	queued := &ImportJob{FileName: testJobFile}
+	if err := db.CreateImportJob(ctx, queued, []byte("payload")); err != nil {
+		t.Fatalf("Failed to create import job: %v", err)
+	}
+	canceled, err := db.CancelImportJob(ctx, queued.ID)
+	if err != nil || canceled.Status != JobCanceled {
+		t.Errorf("want queued job canceled, got %+v and %v", canceled, err)
+	}
+	if _, _, err := db.ClaimImportJob(ctx, "runner-a"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("want a canceled job never claimed, got %v", err)
+	}
+	if _, err := db.GetImportJob(-1); !errors.Is(err, ErrNotFound) {
+		t.Errorf("want ErrNotFound for an unknown job, got %v", err)
+	}
+}
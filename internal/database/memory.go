@@ -0,0 +1,267 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"swift-parser/internal/models"
+	"sync"
+	"time"
+)
+
+// MemoryStore is a thread-safe in-memory SwiftCodeStore. It mirrors the
+// behaviour of the PostgreSQL implementation and is intended for local demos
+// and unit tests.
+type MemoryStore struct {
+	mu      sync.RWMutex
+	codes   map[string]models.SwiftCode
+	deleted map[string]DeletedSwiftCode
+	history []HistoryEntry
+	now     func() time.Time
+
+	jobs      map[int64]*memoryJob
+	lastJobID int64
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		codes:   make(map[string]models.SwiftCode),
+		deleted: make(map[string]DeletedSwiftCode),
+		jobs:    make(map[int64]*memoryJob),
+		now:     time.Now,
+	}
+}
+
+// InsertSwiftCodes upserts all codes, keyed by SWIFT code
+func (m *MemoryStore) InsertSwiftCodes(ctx context.Context, codes []models.SwiftCode) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, code := range codes {
+		m.put(ctx, code)
+	}
+	return nil
+}
+
+// put stores code, bumping the version of an existing entry and recording
+// history only when a value changes, as the PostgreSQL triggers do. A
+// soft-deleted code is restored. Callers must hold mu.
+func (m *MemoryStore) put(ctx context.Context, code models.SwiftCode) models.SwiftCode {
+	existing, ok := m.codes[code.SwiftCode]
+	deleted, isDeleted := m.deleted[code.SwiftCode]
+	switch {
+	case isDeleted:
+		code.Version = deleted.Version + 1
+		delete(m.deleted, code.SwiftCode)
+		m.record(ctx, OperationRestore, nil, &code)
+	case !ok:
+		code.Version = 1
+		m.record(ctx, OperationInsert, nil, &code)
+	default:
+		code.Version = existing.Version
+		if existing != code {
+			code.Version++
+			m.record(ctx, OperationUpdate, &existing, &code)
+		}
+	}
+	m.codes[code.SwiftCode] = code
+	return code
+}
+
+func (m *MemoryStore) GetSWIFTCode(code string) (*models.SwiftCode, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	swiftCode, ok := m.codes[code]
+	if !ok {
+		return nil, fmt.Errorf("swift code %q: %w", code, ErrNotFound)
+	}
+	return &swiftCode, nil
+}
+
+// GetBranches retrieves all branches sharing the first 6 characters of a
+// headquarter SWIFT code
+func (m *MemoryStore) GetBranches(headquarterCode string) ([]models.SwiftCode, error) {
+	if len(headquarterCode) < 6 {
+		return nil, fmt.Errorf("headquarter code %q: %w", headquarterCode, ErrInvalidInput)
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return branchesOf(m.codes, headquarterCode), nil
+}
+
+// branchesOf returns the branches in codes sharing the first 6 characters
+// of headquarterCode, ordered by code
+func branchesOf(codes map[string]models.SwiftCode, headquarterCode string) []models.SwiftCode {
+	baseCode := headquarterCode[:6]
+
+	var branches []models.SwiftCode
+	for _, code := range codes {
+		if code.SwiftCode == headquarterCode || code.IsHeadquarter {
+			continue
+		}
+		if strings.HasPrefix(code.SwiftCode, baseCode) {
+			branches = append(branches, code)
+		}
+	}
+	sort.Slice(branches, func(i, j int) bool {
+		return branches[i].SwiftCode < branches[j].SwiftCode
+	})
+	return branches
+}
+
+// GetSWIFTCodesByCountry retrieves the SWIFT codes of a country, filtered,
+// sorted and paginated by q. For an unfiltered first page it returns
+// ErrNotFound when the country has no codes.
+func (m *MemoryStore) GetSWIFTCodesByCountry(countryISO2 string, q CountryQuery) ([]models.SwiftCode, error) {
+	m.mu.RLock()
+	source := m.codes
+	if !q.AsOf.IsZero() {
+		source = m.snapshotAsOf(q.AsOf)
+	}
+	var codes []models.SwiftCode
+	for _, code := range source {
+		if code.CountryISO2 == countryISO2 {
+			codes = append(codes, code)
+		}
+	}
+	m.mu.RUnlock()
+
+	codes = applyCountryQuery(codes, q)
+	if len(codes) == 0 && q.unfiltered() {
+		return nil, fmt.Errorf("swift codes for country %q: %w", countryISO2, ErrNotFound)
+	}
+	return codes, nil
+}
+
+// GetSWIFTCodesByPrefix retrieves every SWIFT code starting with prefix,
+// ordered by code. It returns ErrNotFound when no code matches.
+func (m *MemoryStore) GetSWIFTCodesByPrefix(prefix string) ([]models.SwiftCode, error) {
+	if prefix == "" {
+		return nil, fmt.Errorf("swift code prefix is required: %w", ErrInvalidInput)
+	}
+
+	m.mu.RLock()
+	var codes []models.SwiftCode
+	for _, code := range m.codes {
+		if strings.HasPrefix(code.SwiftCode, prefix) {
+			codes = append(codes, code)
+		}
+	}
+	m.mu.RUnlock()
+
+	if len(codes) == 0 {
+		return nil, fmt.Errorf("swift codes with prefix %q: %w", prefix, ErrNotFound)
+	}
+	sort.Slice(codes, func(i, j int) bool {
+		return codes[i].SwiftCode < codes[j].SwiftCode
+	})
+	return codes, nil
+}
+
+// ListSWIFTCodes retrieves every SWIFT code, ordered by code
+func (m *MemoryStore) ListSWIFTCodes() ([]models.SwiftCode, error) {
+	m.mu.RLock()
+	codes := make([]models.SwiftCode, 0, len(m.codes))
+	for _, code := range m.codes {
+		codes = append(codes, code)
+	}
+	m.mu.RUnlock()
+
+	sort.Slice(codes, func(i, j int) bool {
+		return codes[i].SwiftCode < codes[j].SwiftCode
+	})
+	return codes, nil
+}
+
+// AddSWIFTCode adds a new SWIFT code, failing if it already exists, and
+// sets its version
+func (m *MemoryStore) AddSWIFTCode(ctx context.Context, code *models.SwiftCode) error {
+	if code == nil || code.SwiftCode == "" {
+		return fmt.Errorf("swift code is required: %w", ErrInvalidInput)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.codes[code.SwiftCode]; ok {
+		return fmt.Errorf("swift code %q: %w", code.SwiftCode, ErrAlreadyExists)
+	}
+	*code = m.put(ctx, *code)
+	return nil
+}
+
+// UpdateSWIFTCode replaces the stored values of an existing SWIFT code and
+// sets code.Version to the resulting version
+func (m *MemoryStore) UpdateSWIFTCode(ctx context.Context, code *models.SwiftCode, opts UpdateOptions) error {
+	if code == nil || code.SwiftCode == "" {
+		return fmt.Errorf("swift code is required: %w", ErrInvalidInput)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.checkVersion(code.SwiftCode, opts.IfVersion); err != nil {
+		return err
+	}
+	*code = m.put(ctx, *code)
+	return nil
+}
+
+// DeleteSWIFTCode soft-deletes a SWIFT code. It can be restored until
+// purged. The branches of a headquarter are handled by opts.Branches.
+func (m *MemoryStore) DeleteSWIFTCode(ctx context.Context, code string, opts DeleteOptions) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.checkVersion(code, opts.IfVersion); err != nil {
+		return err
+	}
+
+	existing := m.codes[code]
+	if existing.IsHeadquarter && opts.Branches != BranchesDetach {
+		branches := branchesOf(m.codes, code)
+		if opts.Branches != BranchesCascade && len(branches) > 0 {
+			codes := make([]string, len(branches))
+			for i, branch := range branches {
+				codes[i] = branch.SwiftCode
+			}
+			return &BranchesExistError{SwiftCode: code, Branches: codes}
+		}
+		for _, branch := range branches {
+			m.softDelete(ctx, branch)
+		}
+	}
+	m.softDelete(ctx, existing)
+	return nil
+}
+
+// softDelete moves code to the tombstones, recording its deletion.
+// Callers must hold mu.
+func (m *MemoryStore) softDelete(ctx context.Context, code models.SwiftCode) {
+	m.record(ctx, OperationDelete, &code, nil)
+	delete(m.codes, code.SwiftCode)
+
+	code.Version++
+	m.deleted[code.SwiftCode] = DeletedSwiftCode{SwiftCode: code, DeletedAt: m.now()}
+}
+
+// checkVersion fails unless code exists and, when version is not zero, is
+// at that version. Callers must hold mu.
+func (m *MemoryStore) checkVersion(code string, version int64) error {
+	existing, ok := m.codes[code]
+	if !ok {
+		return fmt.Errorf("swift code %q: %w", code, ErrNotFound)
+	}
+	if version != 0 && existing.Version != version {
+		return fmt.Errorf("swift code %q: %w", code, ErrVersionMismatch)
+	}
+	return nil
+}
@@ -0,0 +1,549 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"swift-parser/internal/models"
+	"testing"
+	"time"
+)
+
+func seedMemoryStore(t *testing.T) *MemoryStore {
+	store := NewMemoryStore()
+	codes := []models.SwiftCode{
+		{SwiftCode: "TESTTR00XXX", CountryISO2: "TR", CountryName: "TURKEY", BankName: "Test Bank HQ", IsHeadquarter: true},
+		{SwiftCode: "TESTTR00001", CountryISO2: "TR", CountryName: "TURKEY", BankName: "Test Bank Branch"},
+		{SwiftCode: "AAAATR00002", CountryISO2: "TR", CountryName: "TURKEY", BankName: "Other Bank Branch"},
+	}
+	if err := store.InsertSwiftCodes(context.Background(), codes); err != nil {
+		t.Fatalf("Failed to insert test data: %v", err)
+	}
+	return store
+}
+
+func TestMemoryStoreGetSWIFTCode(t *testing.T) {
+	store := seedMemoryStore(t)
+
+	got, err := store.GetSWIFTCode("TESTTR00XXX")
+	if err != nil {
+		t.Fatalf("Failed to get SWIFT code: %v", err)
+	}
+	if got.BankName != "Test Bank HQ" {
+		t.Errorf("want bank name %q, got %q", "Test Bank HQ", got.BankName)
+	}
+
+	if _, err := store.GetSWIFTCode("MISSTR00XXX"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("want ErrNotFound for missing SWIFT code, got %v", err)
+	}
+}
+
+func TestMemoryStoreGetBranches(t *testing.T) {
+	store := seedMemoryStore(t)
+
+	branches, err := store.GetBranches("TESTTR00XXX")
+	if err != nil {
+		t.Fatalf("Failed to get branches: %v", err)
+	}
+	if len(branches) != 1 || branches[0].SwiftCode != "TESTTR00001" {
+		t.Errorf("want branch TESTTR00001, got %+v", branches)
+	}
+}
+
+func TestMemoryStoreGetSWIFTCodesByCountry(t *testing.T) {
+	store := seedMemoryStore(t)
+
+	codes, err := store.GetSWIFTCodesByCountry("TR", CountryQuery{})
+	if err != nil {
+		t.Fatalf("Failed to get country codes: %v", err)
+	}
+
+	want := []string{"TESTTR00XXX", "AAAATR00002", "TESTTR00001"}
+	if len(codes) != len(want) {
+		t.Fatalf("want %d codes, got %d", len(want), len(codes))
+	}
+	for i, code := range codes {
+		if code.SwiftCode != want[i] {
+			t.Errorf("position %d: want %s, got %s", i, want[i], code.SwiftCode)
+		}
+	}
+
+	if _, err := store.GetSWIFTCodesByCountry("PL", CountryQuery{}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("want ErrNotFound for country without codes, got %v", err)
+	}
+}
+
+func TestMemoryStoreCountryQuery(t *testing.T) {
+	store := seedMemoryStore(t)
+	branchesOnly := false
+
+	tests := []struct {
+		name  string
+		query CountryQuery
+		want  []string
+	}{
+		{
+			name:  "Sort by bank name",
+			query: CountryQuery{SortBy: SortBankName},
+			want:  []string{"AAAATR00002", "TESTTR00001", "TESTTR00XXX"},
+		},
+		{
+			name:  "Keyset after first row",
+			query: CountryQuery{SortBy: SortCode, After: &Keyset{SwiftCode: "AAAATR00002"}, Limit: 1},
+			want:  []string{"TESTTR00001"},
+		},
+		{
+			name:  "Keyset in default order",
+			query: CountryQuery{After: &Keyset{SwiftCode: "TESTTR00XXX", IsHeadquarter: true}},
+			want:  []string{"AAAATR00002", "TESTTR00001"},
+		},
+		{
+			name:  "Branches with bank name prefix",
+			query: CountryQuery{IsHeadquarter: &branchesOnly, BankNamePrefix: "test"},
+			want:  []string{"TESTTR00001"},
+		},
+		{
+			name:  "Empty filtered page is not an error",
+			query: CountryQuery{BankNamePrefix: "missing"},
+			want:  []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			codes, err := store.GetSWIFTCodesByCountry("TR", tt.query)
+			if err != nil {
+				t.Fatalf("GetSWIFTCodesByCountry() error = %v", err)
+			}
+			if len(codes) != len(tt.want) {
+				t.Fatalf("want %v, got %+v", tt.want, codes)
+			}
+			for i, code := range codes {
+				if code.SwiftCode != tt.want[i] {
+					t.Errorf("position %d: want %s, got %s", i, tt.want[i], code.SwiftCode)
+				}
+			}
+		})
+	}
+}
+
+func TestMemoryStoreAddAndDelete(t *testing.T) {
+	ctx := context.Background()
+	store := seedMemoryStore(t)
+
+	code := models.SwiftCode{SwiftCode: "NEWBTR00XXX", CountryISO2: "TR", CountryName: "TURKEY", BankName: "New Bank", IsHeadquarter: true}
+	if err := store.AddSWIFTCode(ctx, &code); err != nil {
+		t.Fatalf("Failed to add SWIFT code: %v", err)
+	}
+	if err := store.AddSWIFTCode(ctx, &code); !errors.Is(err, ErrAlreadyExists) {
+		t.Errorf("want ErrAlreadyExists when adding duplicate SWIFT code, got %v", err)
+	}
+
+	code.Address = "New Address"
+	if err := store.UpdateSWIFTCode(ctx, &code, UpdateOptions{}); err != nil {
+		t.Fatalf("Failed to update SWIFT code: %v", err)
+	}
+	if got, _ := store.GetSWIFTCode(code.SwiftCode); got == nil || got.Address != "New Address" {
+		t.Errorf("want updated address, got %+v", got)
+	}
+
+	if err := store.DeleteSWIFTCode(ctx, code.SwiftCode, DeleteOptions{}); err != nil {
+		t.Fatalf("Failed to delete SWIFT code: %v", err)
+	}
+	if err := store.DeleteSWIFTCode(ctx, code.SwiftCode, DeleteOptions{}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("want ErrNotFound when deleting missing SWIFT code, got %v", err)
+	}
+	if err := store.UpdateSWIFTCode(ctx, &code, UpdateOptions{}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("want ErrNotFound when updating missing SWIFT code, got %v", err)
+	}
+}
+
+func TestMemoryStoreSearch(t *testing.T) {
+	ctx := context.Background()
+	store := seedMemoryStore(t)
+	if err := store.AddSWIFTCode(ctx, &models.SwiftCode{
+		SwiftCode: "BANKTR00XXX", CountryISO2: "TR", CountryName: "TURKEY",
+		BankName: "Merchant Trust", Address: "1 Test Street, Istanbul", IsHeadquarter: true,
+	}); err != nil {
+		t.Fatalf("Failed to add SWIFT code: %v", err)
+	}
+
+	results, err := store.SearchSWIFTCodes(SearchQuery{Text: "te"})
+	if err != nil {
+		t.Fatalf("Failed to search: %v", err)
+	}
+	// Bank name matches outrank the address match
+	want := []string{"TESTTR00001", "TESTTR00XXX", "BANKTR00XXX"}
+	if len(results) != len(want) {
+		t.Fatalf("want %d results, got %+v", len(want), results)
+	}
+	for i, code := range want {
+		if results[i].SwiftCode.SwiftCode != code {
+			t.Errorf("want %s at position %d, got %s", code, i, results[i].SwiftCode.SwiftCode)
+		}
+	}
+	if results[0].Rank <= results[2].Rank {
+		t.Errorf("want bank name match ranked above address match, got %v and %v", results[0].Rank, results[2].Rank)
+	}
+
+	isHeadquarter := true
+	results, err = store.SearchSWIFTCodes(SearchQuery{Text: "test bank", IsHeadquarter: &isHeadquarter})
+	if err != nil {
+		t.Fatalf("Failed to search: %v", err)
+	}
+	if len(results) != 1 || results[0].SwiftCode.SwiftCode != "TESTTR00XXX" {
+		t.Errorf("want only TESTTR00XXX, got %+v", results)
+	}
+
+	results, err = store.SearchSWIFTCodes(SearchQuery{Text: "bank", CountryISO2: "PL"})
+	if err != nil || len(results) != 0 {
+		t.Errorf("want no results outside TR, got %+v, %v", results, err)
+	}
+
+	if _, err := store.SearchSWIFTCodes(SearchQuery{Text: " -- "}); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("want ErrInvalidInput for text without words, got %v", err)
+	}
+}
+
+func TestMemoryStoreGetSWIFTCodesByPrefix(t *testing.T) {
+	store := seedMemoryStore(t)
+
+	codes, err := store.GetSWIFTCodesByPrefix("TESTTR")
+	if err != nil {
+		t.Fatalf("Failed to get SWIFT codes by prefix: %v", err)
+	}
+	if len(codes) != 2 || codes[0].SwiftCode != "TESTTR00001" || codes[1].SwiftCode != "TESTTR00XXX" {
+		t.Errorf("want TESTTR00001 and TESTTR00XXX, got %+v", codes)
+	}
+
+	if _, err := store.GetSWIFTCodesByPrefix("MISS"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("want ErrNotFound for unknown prefix, got %v", err)
+	}
+	if _, err := store.GetSWIFTCodesByPrefix(""); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("want ErrInvalidInput for empty prefix, got %v", err)
+	}
+}
+
+func TestMemoryStoreVersions(t *testing.T) {
+	ctx := context.Background()
+	store := seedMemoryStore(t)
+
+	code, err := store.GetSWIFTCode("TESTTR00001")
+	if err != nil {
+		t.Fatalf("Failed to get SWIFT code: %v", err)
+	}
+	if code.Version != 1 {
+		t.Fatalf("want version 1 for a new code, got %d", code.Version)
+	}
+
+	// Writing the same values keeps the version
+	if err := store.UpdateSWIFTCode(ctx, code, UpdateOptions{IfVersion: 1}); err != nil || code.Version != 1 {
+		t.Fatalf("want unchanged update to keep version 1, got %d, %v", code.Version, err)
+	}
+
+	code.Address = "New Address"
+	if err := store.UpdateSWIFTCode(ctx, code, UpdateOptions{IfVersion: 1}); err != nil || code.Version != 2 {
+		t.Fatalf("want update to bump version to 2, got %d, %v", code.Version, err)
+	}
+	if err := store.UpdateSWIFTCode(ctx, code, UpdateOptions{IfVersion: 1}); !errors.Is(err, ErrVersionMismatch) {
+		t.Errorf("want ErrVersionMismatch for stale update, got %v", err)
+	}
+	if err := store.DeleteSWIFTCode(ctx, code.SwiftCode, DeleteOptions{IfVersion: 1}); !errors.Is(err, ErrVersionMismatch) {
+		t.Errorf("want ErrVersionMismatch for stale delete, got %v", err)
+	}
+	if err := store.DeleteSWIFTCode(ctx, code.SwiftCode, DeleteOptions{IfVersion: 2}); err != nil {
+		t.Errorf("Failed to delete SWIFT code at current version: %v", err)
+	}
+}
+
+func TestMemoryStoreHistory(t *testing.T) {
+	ctx := WithAudit(context.Background(), Audit{Actor: "alice", Source: SourceAPI})
+	store := seedMemoryStore(t)
+
+	code, err := store.GetSWIFTCode("TESTTR00001")
+	if err != nil {
+		t.Fatalf("Failed to get SWIFT code: %v", err)
+	}
+	// An update that changes nothing is not recorded
+	if err := store.UpdateSWIFTCode(ctx, code, UpdateOptions{}); err != nil {
+		t.Fatalf("Failed to update SWIFT code: %v", err)
+	}
+	code.Address = "New Address"
+	if err := store.UpdateSWIFTCode(ctx, code, UpdateOptions{}); err != nil {
+		t.Fatalf("Failed to update SWIFT code: %v", err)
+	}
+	if err := store.DeleteSWIFTCode(ctx, code.SwiftCode, DeleteOptions{}); err != nil {
+		t.Fatalf("Failed to delete SWIFT code: %v", err)
+	}
+
+	entries, err := store.GetHistory("TESTTR00001")
+	if err != nil {
+		t.Fatalf("Failed to get history: %v", err)
+	}
+	want := []Operation{OperationInsert, OperationUpdate, OperationDelete}
+	if len(entries) != len(want) {
+		t.Fatalf("want %d history entries, got %+v", len(want), entries)
+	}
+	for i, op := range want {
+		if entries[i].Operation != op {
+			t.Errorf("want %s at position %d, got %s", op, i, entries[i].Operation)
+		}
+	}
+
+	update := entries[1]
+	if update.OldValues.Address != "" || update.NewValues.Address != "New Address" {
+		t.Errorf("want address change recorded, got %+v -> %+v", update.OldValues, update.NewValues)
+	}
+	if update.Actor != "alice" || update.Source != SourceAPI {
+		t.Errorf("want actor alice from api, got %q from %q", update.Actor, update.Source)
+	}
+	if entries[2].NewValues != nil || entries[2].OldValues.Address != "New Address" {
+		t.Errorf("want delete to record the final values, got %+v", entries[2])
+	}
+
+	if _, err := store.GetHistory("MISSTR00XXX"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("want ErrNotFound for code without history, got %v", err)
+	}
+}
+
+func TestMemoryStoreAsOf(t *testing.T) {
+	ctx := context.Background()
+	day := func(d int) time.Time { return time.Date(2025, time.January, d, 0, 0, 0, 0, time.UTC) }
+
+	store := NewMemoryStore()
+	store.now = func() time.Time { return day(1) }
+	seed := []models.SwiftCode{
+		{SwiftCode: "TESTTR00XXX", CountryISO2: "TR", CountryName: "TURKEY", BankName: "Test Bank HQ", IsHeadquarter: true},
+		{SwiftCode: "TESTTR00001", CountryISO2: "TR", CountryName: "TURKEY", BankName: "Test Bank Branch"},
+	}
+	if err := store.InsertSwiftCodes(ctx, seed); err != nil {
+		t.Fatalf("Failed to insert test data: %v", err)
+	}
+
+	store.now = func() time.Time { return day(10) }
+	renamed := seed[0]
+	renamed.BankName = "Renamed Bank"
+	if err := store.UpdateSWIFTCode(ctx, &renamed, UpdateOptions{}); err != nil {
+		t.Fatalf("Failed to update SWIFT code: %v", err)
+	}
+	if err := store.DeleteSWIFTCode(ctx, "TESTTR00001", DeleteOptions{}); err != nil {
+		t.Fatalf("Failed to delete SWIFT code: %v", err)
+	}
+	added := models.SwiftCode{SwiftCode: "TESTTR00002", CountryISO2: "TR", CountryName: "TURKEY", BankName: "New Branch"}
+	if err := store.AddSWIFTCode(ctx, &added); err != nil {
+		t.Fatalf("Failed to add SWIFT code: %v", err)
+	}
+
+	hq, err := store.GetSWIFTCodeAsOf("TESTTR00XXX", day(5))
+	if err != nil || hq.BankName != "Test Bank HQ" {
+		t.Errorf("want original bank name as of day 5, got %+v, %v", hq, err)
+	}
+	hq, err = store.GetSWIFTCodeAsOf("TESTTR00XXX", day(10))
+	if err != nil || hq.BankName != "Renamed Bank" {
+		t.Errorf("want renamed bank as of day 10, got %+v, %v", hq, err)
+	}
+	if _, err := store.GetSWIFTCodeAsOf("TESTTR00XXX", day(1).Add(-time.Second)); !errors.Is(err, ErrNotFound) {
+		t.Errorf("want ErrNotFound before the code existed, got %v", err)
+	}
+
+	branches, err := store.GetBranchesAsOf("TESTTR00XXX", day(5))
+	if err != nil || len(branches) != 1 || branches[0].SwiftCode != "TESTTR00001" {
+		t.Errorf("want deleted branch TESTTR00001 as of day 5, got %+v, %v", branches, err)
+	}
+
+	codes, err := store.GetSWIFTCodesByCountry("TR", CountryQuery{AsOf: day(10)})
+	if err != nil || len(codes) != 2 || codes[1].SwiftCode != "TESTTR00002" {
+		t.Errorf("want TESTTR00XXX and TESTTR00002 as of day 10, got %+v, %v", codes, err)
+	}
+	if _, err := store.GetSWIFTCodesByCountry("TR", CountryQuery{AsOf: day(0)}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("want ErrNotFound for country before any code existed, got %v", err)
+	}
+}
+
+func TestMemoryStoreSoftDelete(t *testing.T) {
+	ctx := context.Background()
+	day := func(d int) time.Time { return time.Date(2025, time.January, d, 0, 0, 0, 0, time.UTC) }
+
+	store := seedMemoryStore(t)
+	store.now = func() time.Time { return day(1) }
+	if err := store.DeleteSWIFTCode(ctx, "TESTTR00001", DeleteOptions{}); err != nil {
+		t.Fatalf("Failed to delete SWIFT code: %v", err)
+	}
+
+	if _, err := store.GetSWIFTCode("TESTTR00001"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("want ErrNotFound for deleted code, got %v", err)
+	}
+	if branches, _ := store.GetBranches("TESTTR00XXX"); len(branches) != 0 {
+		t.Errorf("want deleted branch hidden, got %+v", branches)
+	}
+	deleted, err := store.GetDeletedSWIFTCodes()
+	if err != nil || len(deleted) != 1 || deleted[0].SwiftCode.SwiftCode != "TESTTR00001" || !deleted[0].DeletedAt.Equal(day(1)) {
+		t.Fatalf("want TESTTR00001 deleted on day 1, got %+v, %v", deleted, err)
+	}
+
+	store.now = func() time.Time { return day(5) }
+	if _, err := store.RestoreSWIFTCode(ctx, "TESTTR00001", 24*time.Hour); !errors.Is(err, ErrRetentionExpired) {
+		t.Errorf("want ErrRetentionExpired outside the window, got %v", err)
+	}
+	if _, err := store.RestoreSWIFTCode(ctx, "TESTTR00XXX", 0); !errors.Is(err, ErrAlreadyExists) {
+		t.Errorf("want ErrAlreadyExists restoring a live code, got %v", err)
+	}
+	if _, err := store.RestoreSWIFTCode(ctx, "NOPETR00XXX", 0); !errors.Is(err, ErrNotFound) {
+		t.Errorf("want ErrNotFound restoring an unknown code, got %v", err)
+	}
+
+	restored, err := store.RestoreSWIFTCode(ctx, "TESTTR00001", 7*24*time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to restore SWIFT code: %v", err)
+	}
+	if restored.Version != 3 {
+		t.Errorf("want version 3 after delete and restore, got %d", restored.Version)
+	}
+	if _, err := store.GetSWIFTCode("TESTTR00001"); err != nil {
+		t.Errorf("want restored code visible, got %v", err)
+	}
+	history, _ := store.GetHistory("TESTTR00001")
+	if last := history[len(history)-1]; last.Operation != OperationRestore || last.NewValues == nil {
+		t.Errorf("want restore recorded with new values, got %+v", last)
+	}
+
+	if err := store.DeleteSWIFTCode(ctx, "TESTTR00001", DeleteOptions{}); err != nil {
+		t.Fatalf("Failed to delete SWIFT code: %v", err)
+	}
+	store.now = func() time.Time { return day(20) }
+	if purged, err := store.PurgeDeletedSWIFTCodes(ctx, 30*24*time.Hour); err != nil || purged != 0 {
+		t.Errorf("want nothing purged within 30 days, got %d, %v", purged, err)
+	}
+	if purged, err := store.PurgeDeletedSWIFTCodes(ctx, 10*24*time.Hour); err != nil || purged != 1 {
+		t.Errorf("want 1 code purged after 10 days, got %d, %v", purged, err)
+	}
+	if _, err := store.RestoreSWIFTCode(ctx, "TESTTR00001", 0); !errors.Is(err, ErrNotFound) {
+		t.Errorf("want ErrNotFound restoring a purged code, got %v", err)
+	}
+	if _, err := store.GetHistory("TESTTR00001"); err != nil {
+		t.Errorf("want history kept after purge, got %v", err)
+	}
+}
+
+func TestMemoryStoreDeleteBranchPolicy(t *testing.T) {
+	ctx := context.Background()
+
+	store := seedMemoryStore(t)
+	err := store.DeleteSWIFTCode(ctx, "TESTTR00XXX", DeleteOptions{})
+	var berr *BranchesExistError
+	if !errors.As(err, &berr) || !errors.Is(err, ErrHasBranches) {
+		t.Fatalf("want BranchesExistError by default, got %v", err)
+	}
+	if len(berr.Branches) != 1 || berr.Branches[0] != "TESTTR00001" {
+		t.Errorf("want TESTTR00001 listed, got %v", berr.Branches)
+	}
+	if _, err := store.GetSWIFTCode("TESTTR00XXX"); err != nil {
+		t.Errorf("want headquarter kept after rejected delete, got %v", err)
+	}
+
+	if err := store.DeleteSWIFTCode(ctx, "TESTTR00001", DeleteOptions{Branches: BranchesReject}); err != nil {
+		t.Errorf("want branch deleted regardless of policy, got %v", err)
+	}
+	if err := store.DeleteSWIFTCode(ctx, "TESTTR00XXX", DeleteOptions{}); err != nil {
+		t.Errorf("want headquarter without branches deleted, got %v", err)
+	}
+
+	store = seedMemoryStore(t)
+	if err := store.DeleteSWIFTCode(ctx, "TESTTR00XXX", DeleteOptions{Branches: BranchesDetach}); err != nil {
+		t.Fatalf("Failed to delete with detach: %v", err)
+	}
+	if _, err := store.GetSWIFTCode("TESTTR00001"); err != nil {
+		t.Errorf("want branch kept after detach, got %v", err)
+	}
+
+	store = seedMemoryStore(t)
+	if err := store.DeleteSWIFTCode(ctx, "TESTTR00XXX", DeleteOptions{Branches: BranchesCascade}); err != nil {
+		t.Fatalf("Failed to delete with cascade: %v", err)
+	}
+	if _, err := store.GetSWIFTCode("TESTTR00001"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("want branch deleted by cascade, got %v", err)
+	}
+	if _, err := store.GetSWIFTCode("AAAATR00002"); err != nil {
+		t.Errorf("want unrelated code kept, got %v", err)
+	}
+	if deleted, _ := store.GetDeletedSWIFTCodes(); len(deleted) != 2 {
+		t.Errorf("want headquarter and branch restorable, got %+v", deleted)
+	}
+}
+
+func TestMemoryStoreSync(t *testing.T) {
+	ctx := context.Background()
+	store := seedMemoryStore(t)
+
+	incoming := []models.SwiftCode{
+		{SwiftCode: "TESTTR00XXX", CountryISO2: "TR", CountryName: "TURKEY", BankName: "Renamed Bank", IsHeadquarter: true},
+		{SwiftCode: "NEWBPL00XXX", CountryISO2: "PL", CountryName: "POLAND", BankName: "New Bank", IsHeadquarter: true},
+	}
+
+	_, err := store.SyncSWIFTCodes(ctx, incoming, SyncOptions{MaxRemovedPercent: 50})
+	var terr *SyncThresholdError
+	if !errors.As(err, &terr) || !errors.Is(err, ErrSyncThreshold) || terr.Removed != 2 || terr.Total != 3 {
+		t.Fatalf("want 2 of 3 removals refused, got %v", err)
+	}
+	if _, err := store.GetSWIFTCode("NEWBPL00XXX"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("want nothing written by a refused sync, got %v", err)
+	}
+
+	kept, err := store.SyncSWIFTCodes(ctx, incoming, SyncOptions{Countries: []string{"TR"}, Keep: []string{"TESTTR00001", "AAAATR00002"}})
+	if err != nil || len(kept.Removed) != 0 {
+		t.Fatalf("want kept codes left alone, got %+v, %v", kept, err)
+	}
+
+	result, err := store.SyncSWIFTCodes(ctx, incoming, SyncOptions{Countries: []string{"TR"}, MaxRemovedPercent: 100})
+	if err != nil {
+		t.Fatalf("Failed to sync: %v", err)
+	}
+	if result.Upserted != 1 || result.OutOfScope != 1 || strings.Join(result.Removed, ",") != "AAAATR00002,TESTTR00001" {
+		t.Errorf("want 1 upserted, 1 out of scope and 2 removed, got %+v", result)
+	}
+	if hq, err := store.GetSWIFTCode("TESTTR00XXX"); err != nil || hq.BankName != "Renamed Bank" {
+		t.Errorf("want headquarter updated, got %+v, %v", hq, err)
+	}
+	if _, err := store.GetSWIFTCode("NEWBPL00XXX"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("want out of scope code left out, got %v", err)
+	}
+	if deleted, _ := store.GetDeletedSWIFTCodes(); len(deleted) != 2 {
+		t.Errorf("want removed codes restorable, got %+v", deleted)
+	}
+}
+
+func TestMemoryStoreRecoverImportJobs(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return start }
+
+	live := &ImportJob{FileName: "live.csv"}
+	stopped := &ImportJob{FileName: "stopped.csv"}
+	for _, job := range []*ImportJob{stopped, live} {
+		if err := store.CreateImportJob(ctx, job, []byte("payload")); err != nil {
+			t.Fatalf("Failed to create job: %v", err)
+		}
+		if _, _, err := store.ClaimImportJob(ctx, "server-"+job.FileName); err != nil {
+			t.Fatalf("Failed to claim job: %v", err)
+		}
+	}
+
+	// Only the live server keeps sending heartbeats
+	store.now = func() time.Time { return start.Add(90 * time.Second) }
+	if err := store.UpdateImportJobProgress(ctx, live.ID, 10); err != nil {
+		t.Fatalf("Failed to update progress: %v", err)
+	}
+	store.now = func() time.Time { return start.Add(3 * time.Minute) }
+
+	requeued, failed, err := store.RecoverImportJobs(ctx, 3, 2*time.Minute)
+	if err != nil || requeued != 1 || failed != 0 {
+		t.Fatalf("want only the stale job requeued, got %d requeued, %d failed, %v", requeued, failed, err)
+	}
+	if job, _ := store.GetImportJob(stopped.ID); job.Status != JobQueued || job.ClaimedBy != "" {
+		t.Errorf("want stale job queued and released, got %+v", job)
+	}
+	if job, _ := store.GetImportJob(live.ID); job.Status != JobRunning || job.ClaimedBy != "server-live.csv" {
+		t.Errorf("want live job left running, got %+v", job)
+	}
+}
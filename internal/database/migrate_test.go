@@ -0,0 +1,87 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestMigrations(t *testing.T) {
+	migrations, err := Migrations()
+	if err != nil {
+		t.Fatalf("Migrations() error = %v", err)
+	}
+	if len(migrations) == 0 {
+		t.Fatal("want embedded migrations, got none")
+	}
+
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			t.Errorf("want version %d at position %d, got %d", i+1, i, m.Version)
+		}
+		if m.Up == "" || m.Down == "" {
+			t.Errorf("migration %d_%s is missing its up or down SQL", m.Version, m.Name)
+		}
+	}
+
+	latest, err := LatestSchemaVersion()
+	if err != nil {
+		t.Fatalf("LatestSchemaVersion() error = %v", err)
+	}
+	if latest != migrations[len(migrations)-1].Version {
+		t.Errorf("want latest version %d, got %d", migrations[len(migrations)-1].Version, latest)
+	}
+}
+
+func TestMigrateDownAndUp(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	ctx := context.Background()
+	codes := benchmarkCodes(1)
+	cleanupBenchmarkCodes(t, db, codes)
+	defer cleanupBenchmarkCodes(t, db, codes)
+
+	// A restore in the history has to survive the down migrations
+	if err := db.AddSWIFTCode(ctx, &codes[0]); err != nil {
+		t.Fatalf("Failed to add SWIFT code: %v", err)
+	}
+	if err := db.DeleteSWIFTCode(ctx, codes[0].SwiftCode, DeleteOptions{}); err != nil {
+		t.Fatalf("Failed to delete SWIFT code: %v", err)
+	}
+	if _, err := db.RestoreSWIFTCode(ctx, codes[0].SwiftCode, 0); err != nil {
+		t.Fatalf("Failed to restore SWIFT code: %v", err)
+	}
+
+	latest, err := LatestSchemaVersion()
+	if err != nil {
+		t.Fatalf("LatestSchemaVersion() error = %v", err)
+	}
+	reverted, err := db.MigrateDown(ctx, latest-1)
+	if err != nil {
+		t.Fatalf("Failed to migrate down: %v", err)
+	}
+	if len(reverted) != latest-1 || reverted[0].Version != latest {
+		t.Errorf("want versions %d to 2 reverted newest first, got %+v", latest, reverted)
+	}
+	if version, err := db.SchemaVersion(ctx); err != nil || version != 1 {
+		t.Errorf("want version 1, got %d and %v", version, err)
+	}
+	if err := db.CheckSchemaVersion(ctx); !errors.Is(err, ErrSchemaOutdated) {
+		t.Errorf("want ErrSchemaOutdated, got %v", err)
+	}
+
+	applied, err := db.MigrateUp(ctx)
+	if err != nil {
+		t.Fatalf("Failed to migrate up again: %v", err)
+	}
+	if len(applied) != latest-1 {
+		t.Errorf("want %d migrations applied, got %d", latest-1, len(applied))
+	}
+	if err := db.CheckSchemaVersion(ctx); err != nil {
+		t.Errorf("want the latest version, got %v", err)
+	}
+	if _, err := db.GetSWIFTCode(codes[0].SwiftCode); err != nil {
+		t.Errorf("want the restored code kept, got %v", err)
+	}
+}
@@ -10,4 +10,4 @@ CREATE TABLE IF NOT EXISTS swift_codes (
 );
 
 CREATE INDEX IF NOT EXISTS idx_swift_codes_country_iso2 ON swift_codes(country_iso2);
-CREATE INDEX IF NOT EXISTS idx_swift_codes_swift_code ON swift_codes(swift_code);
\ No newline at end of file
+CREATE INDEX IF NOT EXISTS idx_swift_codes_swift_code ON swift_codes(swift_code);
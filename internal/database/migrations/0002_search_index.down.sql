@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_swift_codes_search;
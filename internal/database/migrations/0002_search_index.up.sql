@@ -0,0 +1,4 @@
+CREATE INDEX IF NOT EXISTS idx_swift_codes_search ON swift_codes USING GIN (
+    (setweight(to_tsvector('simple', bank_name), 'A') ||
+     setweight(to_tsvector('simple', COALESCE(address, '')), 'B'))
+);
@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS swift_codes_updated_at ON swift_codes;
+DROP FUNCTION IF EXISTS swift_codes_set_updated_at();
+ALTER TABLE swift_codes DROP COLUMN IF EXISTS updated_at;
@@ -0,0 +1,21 @@
+ALTER TABLE swift_codes
+    ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP;
+
+UPDATE swift_codes SET updated_at = created_at WHERE created_at IS NOT NULL;
+
+-- Bump updated_at only when a value actually changes, so re-loading an
+-- unchanged file leaves it alone
+CREATE OR REPLACE FUNCTION swift_codes_set_updated_at() RETURNS trigger AS $$
+BEGIN
+    IF (NEW.country_iso2, NEW.country_name, NEW.bank_name, NEW.address, NEW.is_headquarter)
+        IS DISTINCT FROM
+       (OLD.country_iso2, OLD.country_name, OLD.bank_name, OLD.address, OLD.is_headquarter) THEN
+        NEW.updated_at = CURRENT_TIMESTAMP;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER swift_codes_updated_at
+    BEFORE UPDATE ON swift_codes
+    FOR EACH ROW EXECUTE FUNCTION swift_codes_set_updated_at();
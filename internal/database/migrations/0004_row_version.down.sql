@@ -0,0 +1,12 @@
+CREATE OR REPLACE FUNCTION swift_codes_set_updated_at() RETURNS trigger AS $$
+BEGIN
+    IF (NEW.country_iso2, NEW.country_name, NEW.bank_name, NEW.address, NEW.is_headquarter)
+        IS DISTINCT FROM
+       (OLD.country_iso2, OLD.country_name, OLD.bank_name, OLD.address, OLD.is_headquarter) THEN
+        NEW.updated_at = CURRENT_TIMESTAMP;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+ALTER TABLE swift_codes DROP COLUMN IF EXISTS version;
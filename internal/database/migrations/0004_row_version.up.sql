@@ -0,0 +1,16 @@
+ALTER TABLE swift_codes
+    ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1;
+
+-- Bump updated_at and version only when a value actually changes, so
+-- re-loading an unchanged file leaves them alone
+CREATE OR REPLACE FUNCTION swift_codes_set_updated_at() RETURNS trigger AS $$
+BEGIN
+    IF (NEW.country_iso2, NEW.country_name, NEW.bank_name, NEW.address, NEW.is_headquarter)
+        IS DISTINCT FROM
+       (OLD.country_iso2, OLD.country_name, OLD.bank_name, OLD.address, OLD.is_headquarter) THEN
+        NEW.updated_at = CURRENT_TIMESTAMP;
+        NEW.version = OLD.version + 1;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS swift_codes_history ON swift_codes;
+DROP FUNCTION IF EXISTS swift_codes_record_history();
+DROP TABLE IF EXISTS swift_code_history;
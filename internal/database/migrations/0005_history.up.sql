@@ -0,0 +1,70 @@
+CREATE TABLE IF NOT EXISTS swift_code_history (
+    id BIGSERIAL PRIMARY KEY,
+    swift_code VARCHAR(11) NOT NULL,
+    operation VARCHAR(6) NOT NULL CHECK (operation IN ('insert', 'update', 'delete')),
+    old_values JSONB,
+    new_values JSONB,
+    changed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    actor TEXT,
+    source TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_swift_code_history_swift_code ON swift_code_history(swift_code, changed_at);
+
+-- Records every change to swift_codes. The actor and source are read from
+-- the swift.actor and swift.source settings of the writing transaction.
+CREATE OR REPLACE FUNCTION swift_codes_record_history() RETURNS trigger AS $$
+DECLARE
+    code VARCHAR(11);
+    old_json JSONB;
+    new_json JSONB;
+BEGIN
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        code = OLD.swift_code;
+        old_json = jsonb_build_object(
+            'swiftCode', OLD.swift_code,
+            'countryISO2', OLD.country_iso2,
+            'countryName', OLD.country_name,
+            'bankName', OLD.bank_name,
+            'address', OLD.address,
+            'isHeadquarter', OLD.is_headquarter);
+    END IF;
+    IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        code = NEW.swift_code;
+        new_json = jsonb_build_object(
+            'swiftCode', NEW.swift_code,
+            'countryISO2', NEW.country_iso2,
+            'countryName', NEW.country_name,
+            'bankName', NEW.bank_name,
+            'address', NEW.address,
+            'isHeadquarter', NEW.is_headquarter);
+    END IF;
+
+    IF TG_OP = 'UPDATE' AND old_json = new_json THEN
+        RETURN NULL;
+    END IF;
+
+    INSERT INTO swift_code_history (swift_code, operation, old_values, new_values, actor, source)
+    VALUES (code, lower(TG_OP), old_json, new_json,
+            NULLIF(current_setting('swift.actor', true), ''),
+            NULLIF(current_setting('swift.source', true), ''));
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER swift_codes_history
+    AFTER INSERT OR UPDATE OR DELETE ON swift_codes
+    FOR EACH ROW EXECUTE FUNCTION swift_codes_record_history();
+
+-- Existing rows get an insert entry so their history has a starting point
+INSERT INTO swift_code_history (swift_code, operation, new_values, changed_at, source)
+SELECT swift_code, 'insert',
+       jsonb_build_object(
+           'swiftCode', swift_code,
+           'countryISO2', country_iso2,
+           'countryName', country_name,
+           'bankName', bank_name,
+           'address', address,
+           'isHeadquarter', is_headquarter),
+       COALESCE(created_at, CURRENT_TIMESTAMP), 'migration'
+FROM swift_codes;
@@ -0,0 +1,64 @@
+-- Tombstoned rows are removed; their deletes are already in the history
+DELETE FROM swift_codes WHERE deleted_at IS NOT NULL;
+
+CREATE OR REPLACE FUNCTION swift_codes_record_history() RETURNS trigger AS $$
+DECLARE
+    code VARCHAR(11);
+    old_json JSONB;
+    new_json JSONB;
+BEGIN
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        code = OLD.swift_code;
+        old_json = jsonb_build_object(
+            'swiftCode', OLD.swift_code,
+            'countryISO2', OLD.country_iso2,
+            'countryName', OLD.country_name,
+            'bankName', OLD.bank_name,
+            'address', OLD.address,
+            'isHeadquarter', OLD.is_headquarter);
+    END IF;
+    IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        code = NEW.swift_code;
+        new_json = jsonb_build_object(
+            'swiftCode', NEW.swift_code,
+            'countryISO2', NEW.country_iso2,
+            'countryName', NEW.country_name,
+            'bankName', NEW.bank_name,
+            'address', NEW.address,
+            'isHeadquarter', NEW.is_headquarter);
+    END IF;
+
+    IF TG_OP = 'UPDATE' AND old_json = new_json THEN
+        RETURN NULL;
+    END IF;
+
+    INSERT INTO swift_code_history (swift_code, operation, old_values, new_values, actor, source)
+    VALUES (code, lower(TG_OP), old_json, new_json,
+            NULLIF(current_setting('swift.actor', true), ''),
+            NULLIF(current_setting('swift.source', true), ''));
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION swift_codes_set_updated_at() RETURNS trigger AS $$
+BEGIN
+    IF (NEW.country_iso2, NEW.country_name, NEW.bank_name, NEW.address, NEW.is_headquarter)
+        IS DISTINCT FROM
+       (OLD.country_iso2, OLD.country_name, OLD.bank_name, OLD.address, OLD.is_headquarter) THEN
+        NEW.updated_at = CURRENT_TIMESTAMP;
+        NEW.version = OLD.version + 1;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+UPDATE swift_code_history SET operation = 'insert' WHERE operation = 'restore';
+
+ALTER TABLE swift_code_history
+    DROP CONSTRAINT IF EXISTS swift_code_history_operation_check,
+    ADD CONSTRAINT swift_code_history_operation_check
+        CHECK (operation IN ('insert', 'update', 'delete')),
+    ALTER COLUMN operation TYPE VARCHAR(6);
+
+DROP INDEX IF EXISTS idx_swift_codes_deleted_at;
+ALTER TABLE swift_codes DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,85 @@
+ALTER TABLE swift_codes
+    ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_swift_codes_deleted_at ON swift_codes(deleted_at)
+    WHERE deleted_at IS NOT NULL;
+
+-- 'restore' does not fit the VARCHAR(6) of 0005
+ALTER TABLE swift_code_history
+    ALTER COLUMN operation TYPE VARCHAR(7),
+    DROP CONSTRAINT IF EXISTS swift_code_history_operation_check,
+    ADD CONSTRAINT swift_code_history_operation_check
+        CHECK (operation IN ('insert', 'update', 'delete', 'restore'));
+
+-- Deleting or restoring a record also changes its version
+CREATE OR REPLACE FUNCTION swift_codes_set_updated_at() RETURNS trigger AS $$
+BEGIN
+    IF (NEW.country_iso2, NEW.country_name, NEW.bank_name, NEW.address, NEW.is_headquarter, NEW.deleted_at)
+        IS DISTINCT FROM
+       (OLD.country_iso2, OLD.country_name, OLD.bank_name, OLD.address, OLD.is_headquarter, OLD.deleted_at) THEN
+        NEW.updated_at = CURRENT_TIMESTAMP;
+        NEW.version = OLD.version + 1;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Setting deleted_at is recorded as a delete and clearing it as a restore.
+-- Purging a tombstone records nothing, as its delete is already recorded.
+CREATE OR REPLACE FUNCTION swift_codes_record_history() RETURNS trigger AS $$
+DECLARE
+    code VARCHAR(11);
+    op VARCHAR(7);
+    old_json JSONB;
+    new_json JSONB;
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        op = 'insert';
+    ELSIF TG_OP = 'DELETE' THEN
+        IF OLD.deleted_at IS NOT NULL THEN
+            RETURN NULL;
+        END IF;
+        op = 'delete';
+    ELSIF OLD.deleted_at IS NULL AND NEW.deleted_at IS NOT NULL THEN
+        op = 'delete';
+    ELSIF OLD.deleted_at IS NOT NULL AND NEW.deleted_at IS NULL THEN
+        op = 'restore';
+    ELSIF NEW.deleted_at IS NOT NULL THEN
+        -- Changes to a tombstone are invisible until it is restored
+        RETURN NULL;
+    ELSE
+        op = 'update';
+    END IF;
+
+    IF op IN ('update', 'delete') THEN
+        code = OLD.swift_code;
+        old_json = jsonb_build_object(
+            'swiftCode', OLD.swift_code,
+            'countryISO2', OLD.country_iso2,
+            'countryName', OLD.country_name,
+            'bankName', OLD.bank_name,
+            'address', OLD.address,
+            'isHeadquarter', OLD.is_headquarter);
+    END IF;
+    IF op IN ('insert', 'update', 'restore') THEN
+        code = NEW.swift_code;
+        new_json = jsonb_build_object(
+            'swiftCode', NEW.swift_code,
+            'countryISO2', NEW.country_iso2,
+            'countryName', NEW.country_name,
+            'bankName', NEW.bank_name,
+            'address', NEW.address,
+            'isHeadquarter', NEW.is_headquarter);
+    END IF;
+
+    IF op = 'update' AND old_json = new_json THEN
+        RETURN NULL;
+    END IF;
+
+    INSERT INTO swift_code_history (swift_code, operation, old_values, new_values, actor, source)
+    VALUES (code, op, old_json, new_json,
+            NULLIF(current_setting('swift.actor', true), ''),
+            NULLIF(current_setting('swift.source', true), ''));
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
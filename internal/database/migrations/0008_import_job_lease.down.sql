@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_import_jobs_running;
+ALTER TABLE import_jobs
+    DROP COLUMN IF EXISTS updated_at,
+    DROP COLUMN IF EXISTS claimed_by;
@@ -0,0 +1,8 @@
+-- A running job is leased by the server that claimed it and kept alive by
+-- its heartbeat; only jobs whose heartbeat stopped are recovered
+ALTER TABLE import_jobs
+    ADD COLUMN IF NOT EXISTS claimed_by VARCHAR(255),
+    ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_import_jobs_running ON import_jobs(updated_at)
+    WHERE status = 'running';
@@ -0,0 +1,69 @@
+-- The country of each change, so a country can be listed as of a past
+-- time without scanning the whole history
+ALTER TABLE swift_code_history ADD COLUMN IF NOT EXISTS country_iso2 VARCHAR(2);
+
+UPDATE swift_code_history
+SET country_iso2 = COALESCE(new_values, old_values)->>'countryISO2'
+WHERE country_iso2 IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_swift_code_history_country
+    ON swift_code_history(country_iso2, swift_code, changed_at);
+
+-- Unchanged from 0006 but for recording the country of the code
+CREATE OR REPLACE FUNCTION swift_codes_record_history() RETURNS trigger AS $$
+DECLARE
+    code VARCHAR(11);
+    op VARCHAR(7);
+    old_json JSONB;
+    new_json JSONB;
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        op = 'insert';
+    ELSIF TG_OP = 'DELETE' THEN
+        IF OLD.deleted_at IS NOT NULL THEN
+            RETURN NULL;
+        END IF;
+        op = 'delete';
+    ELSIF OLD.deleted_at IS NULL AND NEW.deleted_at IS NOT NULL THEN
+        op = 'delete';
+    ELSIF OLD.deleted_at IS NOT NULL AND NEW.deleted_at IS NULL THEN
+        op = 'restore';
+    ELSIF NEW.deleted_at IS NOT NULL THEN
+        -- Changes to a tombstone are invisible until it is restored
+        RETURN NULL;
+    ELSE
+        op = 'update';
+    END IF;
+
+    IF op IN ('update', 'delete') THEN
+        code = OLD.swift_code;
+        old_json = jsonb_build_object(
+            'swiftCode', OLD.swift_code,
+            'countryISO2', OLD.country_iso2,
+            'countryName', OLD.country_name,
+            'bankName', OLD.bank_name,
+            'address', OLD.address,
+            'isHeadquarter', OLD.is_headquarter);
+    END IF;
+    IF op IN ('insert', 'update', 'restore') THEN
+        code = NEW.swift_code;
+        new_json = jsonb_build_object(
+            'swiftCode', NEW.swift_code,
+            'countryISO2', NEW.country_iso2,
+            'countryName', NEW.country_name,
+            'bankName', NEW.bank_name,
+            'address', NEW.address,
+            'isHeadquarter', NEW.is_headquarter);
+    END IF;
+
+    IF op = 'update' AND old_json = new_json THEN
+        RETURN NULL;
+    END IF;
+
+    INSERT INTO swift_code_history (swift_code, country_iso2, operation, old_values, new_values, actor, source)
+    VALUES (code, COALESCE(new_json, old_json)->>'countryISO2', op, old_json, new_json,
+            NULLIF(current_setting('swift.actor', true), ''),
+            NULLIF(current_setting('swift.source', true), ''));
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
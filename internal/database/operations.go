@@ -4,16 +4,27 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"strings"
 	"swift-parser/internal/models"
 )
 
 func (db *DB) InsertSwiftCodes(ctx context.Context, codes []models.SwiftCode) error {
-	tx, err := db.BeginTx(ctx, nil)
+	tx, err := db.beginAudited(ctx)
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback()
 
+	if err := upsertSwiftCodes(ctx, tx, codes); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// upsertSwiftCodes inserts or replaces codes within tx, restoring any that
+// were soft-deleted
+func upsertSwiftCodes(ctx context.Context, tx *sql.Tx, codes []models.SwiftCode) error {
 	stmt, err := tx.PrepareContext(ctx, `
         INSERT INTO swift_codes (
             swift_code, country_iso2, country_name, 
@@ -24,7 +35,8 @@ func (db *DB) InsertSwiftCodes(ctx context.Context, codes []models.SwiftCode) er
             country_name = $3,
             bank_name = $4,
             address = $5,
-            is_headquarter = $6
+            is_headquarter = $6,
+            deleted_at = NULL
     `)
 	if err != nil {
 		return err
@@ -44,16 +56,16 @@ func (db *DB) InsertSwiftCodes(ctx context.Context, codes []models.SwiftCode) er
 			return err
 		}
 	}
-
-	return tx.Commit()
+	return nil
 }
 
 func (db *DB) GetSWIFTCode(code string) (*models.SwiftCode, error) {
 	query := `
         SELECT swift_code, country_iso2, country_name, 
-               bank_name, address, is_headquarter
+               bank_name, address, is_headquarter, version
         FROM swift_codes 
-        WHERE swift_code = $1`
+        WHERE swift_code = $1
+        AND deleted_at IS NULL`
 
 	var swiftCode models.SwiftCode
 	err := db.QueryRow(query, code).Scan(
@@ -63,9 +75,10 @@ func (db *DB) GetSWIFTCode(code string) (*models.SwiftCode, error) {
 		&swiftCode.BankName,
 		&swiftCode.Address,
 		&swiftCode.IsHeadquarter,
+		&swiftCode.Version,
 	)
-	if err == sql.ErrNoRows {
-		return nil, errors.New("swift code not found")
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("swift code %q: %w", code, ErrNotFound)
 	}
 	if err != nil {
 		return nil, err
@@ -75,14 +88,19 @@ func (db *DB) GetSWIFTCode(code string) (*models.SwiftCode, error) {
 
 // GetBranches retrieves all branches for a headquarter SWIFT code
 func (db *DB) GetBranches(headquarterCode string) ([]models.SwiftCode, error) {
+	if len(headquarterCode) < 6 {
+		return nil, fmt.Errorf("headquarter code %q: %w", headquarterCode, ErrInvalidInput)
+	}
+
 	// Get base code (first 6 characters) and add wildcard
 	baseCode := headquarterCode[:6] + "%"
 
 	query := `
         SELECT swift_code, country_iso2, bank_name, 
-               address, is_headquarter
+               address, is_headquarter, version
         FROM swift_codes 
-        WHERE swift_code LIKE $1 
+        WHERE swift_code LIKE $1
+        AND deleted_at IS NULL
         AND swift_code != $2 
         AND NOT is_headquarter`
 
@@ -101,6 +119,7 @@ func (db *DB) GetBranches(headquarterCode string) ([]models.SwiftCode, error) {
 			&branch.BankName,
 			&branch.Address,
 			&branch.IsHeadquarter,
+			&branch.Version,
 		)
 		if err != nil {
 			return nil, err
@@ -110,16 +129,58 @@ func (db *DB) GetBranches(headquarterCode string) ([]models.SwiftCode, error) {
 	return branches, nil
 }
 
-// GetSWIFTCodesByCountry retrieves all SWIFT codes for a specific country
-func (db *DB) GetSWIFTCodesByCountry(countryISO2 string) ([]models.SwiftCode, error) {
+// GetSWIFTCodesByCountry retrieves the SWIFT codes of a country, filtered,
+// sorted and paginated by q using keyset pagination. For an unfiltered first
+// page it returns ErrNotFound when the country has no codes.
+func (db *DB) GetSWIFTCodesByCountry(countryISO2 string, q CountryQuery) ([]models.SwiftCode, error) {
+	if !q.AsOf.IsZero() {
+		return db.getSWIFTCodesByCountryAsOf(countryISO2, q)
+	}
+
 	query := `
         SELECT swift_code, country_iso2, country_name,
-               bank_name, address, is_headquarter
+               bank_name, address, is_headquarter, version
         FROM swift_codes 
         WHERE country_iso2 = $1
-        ORDER BY is_headquarter DESC, swift_code`
+        AND deleted_at IS NULL`
+	args := []any{countryISO2}
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if q.IsHeadquarter != nil {
+		query += ` AND is_headquarter = ` + arg(*q.IsHeadquarter)
+	}
+	if q.BankNamePrefix != "" {
+		query += ` AND upper(bank_name) LIKE ` + arg(escapeLike(strings.ToUpper(q.BankNamePrefix))+"%")
+	}
+
+	// Compare in byte order so keysets match the in-memory store
+	switch q.SortBy {
+	case SortBankName:
+		if q.After != nil {
+			query += fmt.Sprintf(` AND (bank_name COLLATE "C", swift_code COLLATE "C") > (%s, %s)`,
+				arg(q.After.BankName), arg(q.After.SwiftCode))
+		}
+		query += ` ORDER BY bank_name COLLATE "C", swift_code COLLATE "C"`
+	case SortCode:
+		if q.After != nil {
+			query += ` AND swift_code COLLATE "C" > ` + arg(q.After.SwiftCode)
+		}
+		query += ` ORDER BY swift_code COLLATE "C"`
+	default:
+		if q.After != nil {
+			query += fmt.Sprintf(` AND (NOT is_headquarter, swift_code COLLATE "C") > (NOT %s::boolean, %s)`,
+				arg(q.After.IsHeadquarter), arg(q.After.SwiftCode))
+		}
+		query += ` ORDER BY is_headquarter DESC, swift_code COLLATE "C"`
+	}
+	if q.Limit > 0 {
+		query += ` LIMIT ` + arg(q.Limit)
+	}
 
-	rows, err := db.Query(query, countryISO2)
+	rows, err := db.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -135,53 +196,305 @@ func (db *DB) GetSWIFTCodesByCountry(countryISO2 string) ([]models.SwiftCode, er
 			&code.BankName,
 			&code.Address,
 			&code.IsHeadquarter,
+			&code.Version,
 		)
 		if err != nil {
 			return nil, err
 		}
 		codes = append(codes, code)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(codes) == 0 && q.unfiltered() {
+		return nil, fmt.Errorf("swift codes for country %q: %w", countryISO2, ErrNotFound)
+	}
+	return codes, nil
+}
+
+// GetSWIFTCodesByPrefix retrieves every SWIFT code starting with prefix,
+// ordered by code. It returns ErrNotFound when no code matches.
+func (db *DB) GetSWIFTCodesByPrefix(prefix string) ([]models.SwiftCode, error) {
+	if prefix == "" {
+		return nil, fmt.Errorf("swift code prefix is required: %w", ErrInvalidInput)
+	}
+
+	query := `
+        SELECT swift_code, country_iso2, country_name,
+               bank_name, address, is_headquarter, version
+        FROM swift_codes
+        WHERE swift_code LIKE $1
+        AND deleted_at IS NULL
+        ORDER BY swift_code COLLATE "C"`
+
+	rows, err := db.Query(query, escapeLike(prefix)+"%")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var codes []models.SwiftCode
+	for rows.Next() {
+		var code models.SwiftCode
+		err := rows.Scan(
+			&code.SwiftCode,
+			&code.CountryISO2,
+			&code.CountryName,
+			&code.BankName,
+			&code.Address,
+			&code.IsHeadquarter,
+			&code.Version,
+		)
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
 	if len(codes) == 0 {
-		return nil, errors.New("no swift codes found for this country")
+		return nil, fmt.Errorf("swift codes with prefix %q: %w", prefix, ErrNotFound)
 	}
 	return codes, nil
 }
 
-// AddSWIFTCode adds a new SWIFT code to the database
-func (db *DB) AddSWIFTCode(code *models.SwiftCode) error {
+// ListSWIFTCodes retrieves every SWIFT code, ordered by code
+func (db *DB) ListSWIFTCodes() ([]models.SwiftCode, error) {
+	query := `
+        SELECT swift_code, country_iso2, country_name,
+               bank_name, address, is_headquarter, version
+        FROM swift_codes
+        WHERE deleted_at IS NULL
+        ORDER BY swift_code COLLATE "C"`
+
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var codes []models.SwiftCode
+	for rows.Next() {
+		var code models.SwiftCode
+		err := rows.Scan(
+			&code.SwiftCode,
+			&code.CountryISO2,
+			&code.CountryName,
+			&code.BankName,
+			&code.Address,
+			&code.IsHeadquarter,
+			&code.Version,
+		)
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+	}
+	return codes, rows.Err()
+}
+
+// escapeLike escapes the LIKE wildcards in s
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// AddSWIFTCode adds a new SWIFT code to the database and sets its version.
+// A soft-deleted code is restored with the new values.
+func (db *DB) AddSWIFTCode(ctx context.Context, code *models.SwiftCode) error {
+	if code == nil || code.SwiftCode == "" {
+		return fmt.Errorf("swift code is required: %w", ErrInvalidInput)
+	}
+
+	tx, err := db.beginAudited(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	query := `
         INSERT INTO swift_codes (
             swift_code, country_iso2, country_name,
             bank_name, address, is_headquarter
-        ) VALUES ($1, $2, $3, $4, $5, $6)`
+        ) VALUES ($1, $2, $3, $4, $5, $6)
+        ON CONFLICT (swift_code) DO UPDATE SET
+            country_iso2 = EXCLUDED.country_iso2,
+            country_name = EXCLUDED.country_name,
+            bank_name = EXCLUDED.bank_name,
+            address = EXCLUDED.address,
+            is_headquarter = EXCLUDED.is_headquarter,
+            deleted_at = NULL
+        WHERE swift_codes.deleted_at IS NOT NULL
+        RETURNING version`
 
-	_, err := db.Exec(query,
+	err = tx.QueryRowContext(ctx, query,
 		code.SwiftCode,
 		code.CountryISO2,
 		code.CountryName,
 		code.BankName,
 		code.Address,
 		code.IsHeadquarter,
-	)
-	return err
+	).Scan(&code.Version)
+	if errors.Is(err, sql.ErrNoRows) || isUniqueViolation(err) {
+		return fmt.Errorf("swift code %q: %w", code.SwiftCode, ErrAlreadyExists)
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
-// DeleteSWIFTCode deletes a SWIFT code from the database
-func (db *DB) DeleteSWIFTCode(code string) error {
-	query := `DELETE FROM swift_codes WHERE swift_code = $1`
+// UpdateSWIFTCode replaces the stored values of an existing SWIFT code,
+// keeping its created_at, and sets code.Version to the resulting version.
+// updated_at and version are maintained by a trigger.
+func (db *DB) UpdateSWIFTCode(ctx context.Context, code *models.SwiftCode, opts UpdateOptions) error {
+	if code == nil || code.SwiftCode == "" {
+		return fmt.Errorf("swift code is required: %w", ErrInvalidInput)
+	}
+
+	tx, err := db.beginAudited(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+        UPDATE swift_codes SET
+            country_iso2 = $2,
+            country_name = $3,
+            bank_name = $4,
+            address = $5,
+            is_headquarter = $6
+        WHERE swift_code = $1
+        AND deleted_at IS NULL
+        AND ($7 = 0 OR version = $7)
+        RETURNING version`
 
-	result, err := db.Exec(query, code)
+	err = tx.QueryRowContext(ctx, query,
+		code.SwiftCode,
+		code.CountryISO2,
+		code.CountryName,
+		code.BankName,
+		code.Address,
+		code.IsHeadquarter,
+		opts.IfVersion,
+	).Scan(&code.Version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return missingOrConflict(ctx, tx, code.SwiftCode)
+	}
 	if err != nil {
 		return err
 	}
+	return tx.Commit()
+}
 
-	rowsAffected, err := result.RowsAffected()
+// DeleteSWIFTCode soft-deletes a SWIFT code by setting its deleted_at
+// tombstone. It can be restored until purged. The branches of a
+// headquarter are handled by opts.Branches in the same transaction.
+func (db *DB) DeleteSWIFTCode(ctx context.Context, code string, opts DeleteOptions) error {
+	tx, err := db.beginAudited(ctx)
 	if err != nil {
 		return err
 	}
-	if rowsAffected == 0 {
-		return errors.New("swift code not found")
+	defer tx.Rollback()
+
+	query := `
+        UPDATE swift_codes SET deleted_at = CURRENT_TIMESTAMP
+        WHERE swift_code = $1
+        AND deleted_at IS NULL
+        AND ($2 = 0 OR version = $2)
+        RETURNING is_headquarter`
+
+	var isHeadquarter bool
+	err = tx.QueryRowContext(ctx, query, code, opts.IfVersion).Scan(&isHeadquarter)
+	if errors.Is(err, sql.ErrNoRows) {
+		return missingOrConflict(ctx, tx, code)
+	}
+	if err != nil {
+		return err
+	}
+
+	if isHeadquarter && opts.Branches != BranchesDetach {
+		if err := deleteBranches(ctx, tx, code, opts.Branches); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// deleteBranches soft-deletes the branches of headquarterCode under the
+// cascade policy and otherwise fails with a BranchesExistError if it has
+// any
+func deleteBranches(ctx context.Context, tx *sql.Tx, headquarterCode string, policy BranchPolicy) error {
+	where := `
+        WHERE swift_code LIKE $1
+        AND swift_code <> $2
+        AND NOT is_headquarter
+        AND deleted_at IS NULL`
+	args := []any{escapeLike(headquarterCode[:6]) + "%", headquarterCode}
+
+	if policy == BranchesCascade {
+		_, err := tx.ExecContext(ctx, `UPDATE swift_codes SET deleted_at = CURRENT_TIMESTAMP`+where, args...)
+		return err
+	}
+
+	rows, err := tx.QueryContext(ctx, `SELECT swift_code FROM swift_codes`+where+` ORDER BY swift_code`, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var branches []string
+	for rows.Next() {
+		var branch string
+		if err := rows.Scan(&branch); err != nil {
+			return err
+		}
+		branches = append(branches, branch)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(branches) > 0 {
+		return &BranchesExistError{SwiftCode: headquarterCode, Branches: branches}
 	}
 	return nil
 }
+
+// missingOrConflict explains why a conditional write to code matched no
+// row: ErrNotFound if it does not exist, ErrVersionMismatch otherwise
+func missingOrConflict(ctx context.Context, q queryer, code string) error {
+	var exists bool
+	err := q.QueryRowContext(ctx,
+		`SELECT EXISTS (SELECT 1 FROM swift_codes WHERE swift_code = $1 AND deleted_at IS NULL)`, code).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("swift code %q: %w", code, ErrNotFound)
+	}
+	return fmt.Errorf("swift code %q: %w", code, ErrVersionMismatch)
+}
+
+// TruncateSwiftCodes permanently removes every SWIFT code, including
+// soft-deleted ones. Rows are deleted rather than truncated so each removal
+// is recorded in the history.
+func (db *DB) TruncateSwiftCodes(ctx context.Context) error {
+	tx, err := db.beginAudited(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := truncateSwiftCodes(ctx, tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func truncateSwiftCodes(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, `DELETE FROM swift_codes`)
+	return err
+}
@@ -2,26 +2,20 @@ package database
 
 import (
 	"context"
-	"os"
+	"errors"
 	"swift-parser/internal/models"
 	"testing"
 )
 
-func setupTestDB(t *testing.T) *DB {
+func setupTestDB(t testing.TB) *DB {
 	db, err := NewDB("host=localhost port=5432 user=postgres password=3107 dbname=postgres sslmode=disable")
 	if err != nil {
 		t.Fatalf("Failed to connect to database: %v", err)
 	}
 
 	// Create schema
-	schemaSQL, err := os.ReadFile("../../internal/database/schema.sql")
-	if err != nil {
-		t.Fatalf("Failed to read schema file: %v", err)
-	}
-
-	_, err = db.Exec(string(schemaSQL))
-	if err != nil {
-		t.Fatalf("Failed to create schema: %v", err)
+	if _, err := db.MigrateUp(context.Background()); err != nil {
+		t.Fatalf("Failed to migrate schema: %v", err)
 	}
 
 	return db
@@ -100,3 +94,26 @@ func TestGetBranches(t *testing.T) {
 		t.Errorf("want branch code %s, got %s", branch.SwiftCode, branches[0].SwiftCode)
 	}
 }
+
+func TestAddSWIFTCodeDuplicate(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	testCode := models.SwiftCode{
+		SwiftCode:     "TESTTR00XXX",
+		CountryISO2:   "TR",
+		CountryName:   "Turkey",
+		BankName:      "Test Bank",
+		Address:       "Test Address",
+		IsHeadquarter: true,
+	}
+
+	err := db.InsertSwiftCodes(context.Background(), []models.SwiftCode{testCode})
+	if err != nil {
+		t.Fatalf("Failed to insert SWIFT code: %v", err)
+	}
+
+	if err := db.AddSWIFTCode(context.Background(), &testCode); !errors.Is(err, ErrAlreadyExists) {
+		t.Errorf("want ErrAlreadyExists, got %v", err)
+	}
+}
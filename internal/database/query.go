@@ -0,0 +1,100 @@
+package database
+
+import (
+	"sort"
+	"strings"
+	"swift-parser/internal/models"
+	"time"
+)
+
+// SortField selects the order of a country listing
+type SortField string
+
+const (
+	// SortDefault lists headquarters first, then orders by SWIFT code
+	SortDefault SortField = ""
+	// SortCode orders by SWIFT code
+	SortCode SortField = "swiftCode"
+	// SortBankName orders by bank name, then by SWIFT code
+	SortBankName SortField = "bankName"
+)
+
+// Keyset holds the sort values of the last row of a page. Listing after it
+// resumes with the next row in the same order.
+type Keyset struct {
+	SwiftCode     string
+	BankName      string
+	IsHeadquarter bool
+}
+
+// KeysetOf returns the keyset of code
+func KeysetOf(code models.SwiftCode) Keyset {
+	return Keyset{SwiftCode: code.SwiftCode, BankName: code.BankName, IsHeadquarter: code.IsHeadquarter}
+}
+
+// CountryQuery filters, sorts and paginates a country listing. The zero
+// value lists every code, headquarters first.
+type CountryQuery struct {
+	// IsHeadquarter keeps only headquarters or only branches when set
+	IsHeadquarter *bool
+	// BankNamePrefix keeps codes whose bank name starts with it, ignoring case
+	BankNamePrefix string
+	SortBy         SortField
+	// After resumes the listing after the given row
+	After *Keyset
+	// Limit caps the number of codes returned; zero means no limit
+	Limit int
+	// AsOf lists the codes as they were at that time, reconstructed from
+	// the change history; zero lists the current codes
+	AsOf time.Time
+}
+
+// unfiltered reports whether q is a first page over the whole country, in
+// which case an empty result means the country has no codes at all
+func (q CountryQuery) unfiltered() bool {
+	return q.IsHeadquarter == nil && q.BankNamePrefix == "" && q.After == nil
+}
+
+// less reports whether a sorts before b in the order selected by q
+func (q CountryQuery) less(a, b Keyset) bool {
+	switch q.SortBy {
+	case SortBankName:
+		if a.BankName != b.BankName {
+			return a.BankName < b.BankName
+		}
+	case SortCode:
+	default:
+		if a.IsHeadquarter != b.IsHeadquarter {
+			return a.IsHeadquarter
+		}
+	}
+	return a.SwiftCode < b.SwiftCode
+}
+
+// applyCountryQuery filters, sorts and paginates codes of a single country
+// in Go, matching the PostgreSQL implementation
+func applyCountryQuery(codes []models.SwiftCode, q CountryQuery) []models.SwiftCode {
+	prefix := strings.ToUpper(q.BankNamePrefix)
+
+	var result []models.SwiftCode
+	for _, code := range codes {
+		if q.IsHeadquarter != nil && code.IsHeadquarter != *q.IsHeadquarter {
+			continue
+		}
+		if prefix != "" && !strings.HasPrefix(strings.ToUpper(code.BankName), prefix) {
+			continue
+		}
+		if q.After != nil && !q.less(*q.After, KeysetOf(code)) {
+			continue
+		}
+		result = append(result, code)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return q.less(KeysetOf(result[i]), KeysetOf(result[j]))
+	})
+	if q.Limit > 0 && len(result) > q.Limit {
+		result = result[:q.Limit]
+	}
+	return result
+}
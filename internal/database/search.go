@@ -0,0 +1,179 @@
+package database
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"swift-parser/internal/models"
+	"unicode"
+)
+
+// SearchQuery describes a full-text search over bank names and addresses.
+// Every term in Text must match the start of a word.
+type SearchQuery struct {
+	Text string
+	// CountryISO2 restricts results to one country when set
+	CountryISO2 string
+	// IsHeadquarter keeps only headquarters or only branches when set
+	IsHeadquarter *bool
+	// Limit caps the number of results; zero means no limit
+	Limit int
+}
+
+// SearchResult is a matching SWIFT code with its relevance; higher ranks
+// match better
+type SearchResult struct {
+	models.SwiftCode
+	Rank float64
+}
+
+// Relative weights of matches in the bank name and the address, matching
+// the default ts_rank weights of the A and B labels
+const (
+	bankNameWeight = 1.0
+	addressWeight  = 0.4
+)
+
+// searchDocument is the weighted tsvector indexed by idx_swift_codes_search
+const searchDocument = `(setweight(to_tsvector('simple', bank_name), 'A') ||
+     setweight(to_tsvector('simple', COALESCE(address, '')), 'B'))`
+
+// searchTerms splits text into lower-case words of letters and digits
+func searchTerms(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// prefixTSQuery builds a tsquery matching words starting with every term
+func prefixTSQuery(terms []string) string {
+	parts := make([]string, len(terms))
+	for i, term := range terms {
+		parts[i] = term + ":*"
+	}
+	return strings.Join(parts, " & ")
+}
+
+// SearchSWIFTCodes ranks SWIFT codes by how well their bank name and address
+// match q using PostgreSQL full-text search
+func (db *DB) SearchSWIFTCodes(q SearchQuery) ([]SearchResult, error) {
+	terms := searchTerms(q.Text)
+	if len(terms) == 0 {
+		return nil, fmt.Errorf("search text has no words: %w", ErrInvalidInput)
+	}
+
+	query := `
+        SELECT swift_code, country_iso2, country_name,
+               bank_name, address, is_headquarter, version,
+               ts_rank(` + searchDocument + `, to_tsquery('simple', $1)) AS rank
+        FROM swift_codes
+        WHERE deleted_at IS NULL
+        AND ` + searchDocument + ` @@ to_tsquery('simple', $1)`
+	args := []any{prefixTSQuery(terms)}
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if q.CountryISO2 != "" {
+		query += ` AND country_iso2 = ` + arg(q.CountryISO2)
+	}
+	if q.IsHeadquarter != nil {
+		query += ` AND is_headquarter = ` + arg(*q.IsHeadquarter)
+	}
+	query += ` ORDER BY rank DESC, swift_code`
+	if q.Limit > 0 {
+		query += ` LIMIT ` + arg(q.Limit)
+	}
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []SearchResult
+	for rows.Next() {
+		var result SearchResult
+		err := rows.Scan(
+			&result.SwiftCode.SwiftCode,
+			&result.CountryISO2,
+			&result.CountryName,
+			&result.BankName,
+			&result.Address,
+			&result.IsHeadquarter,
+			&result.Version,
+			&result.Rank,
+		)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	return results, rows.Err()
+}
+
+// SearchSWIFTCodes ranks SWIFT codes by how well their bank name and address
+// match q. Each term scores bankNameWeight when it starts a word of the bank
+// name, or addressWeight when it only starts a word of the address.
+func (m *MemoryStore) SearchSWIFTCodes(q SearchQuery) ([]SearchResult, error) {
+	terms := searchTerms(q.Text)
+	if len(terms) == 0 {
+		return nil, fmt.Errorf("search text has no words: %w", ErrInvalidInput)
+	}
+
+	m.mu.RLock()
+	var results []SearchResult
+	for _, code := range m.codes {
+		if q.CountryISO2 != "" && code.CountryISO2 != q.CountryISO2 {
+			continue
+		}
+		if q.IsHeadquarter != nil && code.IsHeadquarter != *q.IsHeadquarter {
+			continue
+		}
+		if rank, ok := matchTerms(terms, code); ok {
+			results = append(results, SearchResult{SwiftCode: code, Rank: rank})
+		}
+	}
+	m.mu.RUnlock()
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Rank != results[j].Rank {
+			return results[i].Rank > results[j].Rank
+		}
+		return results[i].SwiftCode.SwiftCode < results[j].SwiftCode.SwiftCode
+	})
+	if q.Limit > 0 && len(results) > q.Limit {
+		results = results[:q.Limit]
+	}
+	return results, nil
+}
+
+// matchTerms reports whether every term starts a word of the bank name or
+// address of code, and the resulting rank
+func matchTerms(terms []string, code models.SwiftCode) (float64, bool) {
+	bankWords := searchTerms(code.BankName)
+	addressWords := searchTerms(code.Address)
+
+	rank := 0.0
+	for _, term := range terms {
+		switch {
+		case hasWordPrefix(bankWords, term):
+			rank += bankNameWeight
+		case hasWordPrefix(addressWords, term):
+			rank += addressWeight
+		default:
+			return 0, false
+		}
+	}
+	return rank / float64(len(terms)), true
+}
+
+func hasWordPrefix(words []string, prefix string) bool {
+	for _, word := range words {
+		if strings.HasPrefix(word, prefix) {
+			return true
+		}
+	}
+	return false
+}
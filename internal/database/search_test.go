@@ -0,0 +1,53 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestSearchSWIFTCodes(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	ctx := context.Background()
+	codes := antarcticCodes(benchmarkCodes(3))
+	cleanupBenchmarkCodes(t, db, codes)
+	defer cleanupBenchmarkCodes(t, db, codes)
+
+	// ZZYZX appears in no real bank name or address
+	codes[0].BankName, codes[0].Address = "ZZYZX POLAR BANK", "STATION ROAD"
+	codes[1].BankName, codes[1].Address = "SOUTHERN BANK", "ZZYZX AVENUE"
+	codes[2].BankName, codes[2].Address = "ZZYZX POLAR BANK", "STATION ROAD"
+	if err := db.InsertSwiftCodes(ctx, codes); err != nil {
+		t.Fatalf("Failed to insert SWIFT codes: %v", err)
+	}
+	if err := db.DeleteSWIFTCode(ctx, codes[2].SwiftCode, DeleteOptions{}); err != nil {
+		t.Fatalf("Failed to delete SWIFT code: %v", err)
+	}
+
+	results, err := db.SearchSWIFTCodes(SearchQuery{Text: "zzyz", CountryISO2: "AQ"})
+	if err != nil {
+		t.Fatalf("Failed to search: %v", err)
+	}
+	if len(results) != 2 || results[0].SwiftCode.SwiftCode != codes[0].SwiftCode || results[1].SwiftCode.SwiftCode != codes[1].SwiftCode {
+		t.Fatalf("want the bank name match ranked above the address match and the deleted code left out, got %+v", results)
+	}
+	if results[0].Rank <= results[1].Rank {
+		t.Errorf("want rank %f above %f", results[0].Rank, results[1].Rank)
+	}
+
+	if results, err := db.SearchSWIFTCodes(SearchQuery{Text: "Zzyzx, pol"}); err != nil || len(results) != 1 {
+		t.Errorf("want every term matched, got %+v and %v", results, err)
+	}
+	if results, err := db.SearchSWIFTCodes(SearchQuery{Text: "zzyzx", Limit: 1}); err != nil || len(results) != 1 {
+		t.Errorf("want results limited to 1, got %+v and %v", results, err)
+	}
+	isHeadquarter := true
+	if results, err := db.SearchSWIFTCodes(SearchQuery{Text: "zzyzx", IsHeadquarter: &isHeadquarter}); err != nil || len(results) != 0 {
+		t.Errorf("want no headquarters, got %+v and %v", results, err)
+	}
+	if _, err := db.SearchSWIFTCodes(SearchQuery{Text: "++"}); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("want ErrInvalidInput without words, got %v", err)
+	}
+}
@@ -0,0 +1,204 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"swift-parser/internal/models"
+	"time"
+)
+
+// DeletedSwiftCode is a soft-deleted SWIFT code awaiting restore or purge
+type DeletedSwiftCode struct {
+	models.SwiftCode
+	DeletedAt time.Time
+}
+
+// GetDeletedSWIFTCodes lists the soft-deleted SWIFT codes, most recently
+// deleted first
+func (db *DB) GetDeletedSWIFTCodes() ([]DeletedSwiftCode, error) {
+	query := `
+        SELECT swift_code, country_iso2, country_name,
+               bank_name, address, is_headquarter, version, deleted_at
+        FROM swift_codes
+        WHERE deleted_at IS NOT NULL
+        ORDER BY deleted_at DESC, swift_code`
+
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var codes []DeletedSwiftCode
+	for rows.Next() {
+		var code DeletedSwiftCode
+		err := rows.Scan(
+			&code.SwiftCode.SwiftCode,
+			&code.CountryISO2,
+			&code.CountryName,
+			&code.BankName,
+			&code.Address,
+			&code.IsHeadquarter,
+			&code.Version,
+			&code.DeletedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+	}
+	return codes, rows.Err()
+}
+
+// RestoreSWIFTCode clears the tombstone of a soft-deleted SWIFT code and
+// returns the restored record. It returns ErrNotFound if code was never
+// stored or has been purged, ErrAlreadyExists if it is not deleted and
+// ErrRetentionExpired if it was deleted more than retention ago. A zero
+// retention allows any tombstone to be restored.
+func (db *DB) RestoreSWIFTCode(ctx context.Context, code string, retention time.Duration) (*models.SwiftCode, error) {
+	tx, err := db.beginAudited(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var deletedAt sql.NullTime
+	err = tx.QueryRowContext(ctx,
+		`SELECT deleted_at FROM swift_codes WHERE swift_code = $1 FOR UPDATE`, code).Scan(&deletedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("deleted swift code %q: %w", code, ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := checkRestorable(code, deletedAt, retention, time.Now()); err != nil {
+		return nil, err
+	}
+
+	query := `
+        UPDATE swift_codes SET deleted_at = NULL
+        WHERE swift_code = $1
+        RETURNING swift_code, country_iso2, country_name,
+                  bank_name, address, is_headquarter, version`
+
+	var restored models.SwiftCode
+	err = tx.QueryRowContext(ctx, query, code).Scan(
+		&restored.SwiftCode,
+		&restored.CountryISO2,
+		&restored.CountryName,
+		&restored.BankName,
+		&restored.Address,
+		&restored.IsHeadquarter,
+		&restored.Version,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &restored, nil
+}
+
+// checkRestorable fails unless deletedAt marks a tombstone still within
+// retention at now
+func checkRestorable(code string, deletedAt sql.NullTime, retention time.Duration, now time.Time) error {
+	if !deletedAt.Valid {
+		return fmt.Errorf("swift code %q is not deleted: %w", code, ErrAlreadyExists)
+	}
+	if retention > 0 && now.Sub(deletedAt.Time) > retention {
+		return fmt.Errorf("swift code %q deleted at %s: %w",
+			code, deletedAt.Time.Format(time.RFC3339), ErrRetentionExpired)
+	}
+	return nil
+}
+
+// PurgeDeletedSWIFTCodes permanently removes the SWIFT codes soft-deleted
+// more than olderThan ago and returns how many were removed. Their history
+// is kept.
+func (db *DB) PurgeDeletedSWIFTCodes(ctx context.Context, olderThan time.Duration) (int, error) {
+	tx, err := db.beginAudited(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+        DELETE FROM swift_codes
+        WHERE deleted_at IS NOT NULL
+        AND deleted_at < $1`, time.Now().Add(-olderThan))
+	if err != nil {
+		return 0, err
+	}
+	purged, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(purged), tx.Commit()
+}
+
+// GetDeletedSWIFTCodes lists the soft-deleted SWIFT codes, most recently
+// deleted first
+func (m *MemoryStore) GetDeletedSWIFTCodes() ([]DeletedSwiftCode, error) {
+	m.mu.RLock()
+	codes := make([]DeletedSwiftCode, 0, len(m.deleted))
+	for _, code := range m.deleted {
+		codes = append(codes, code)
+	}
+	m.mu.RUnlock()
+
+	sort.Slice(codes, func(i, j int) bool {
+		if !codes[i].DeletedAt.Equal(codes[j].DeletedAt) {
+			return codes[i].DeletedAt.After(codes[j].DeletedAt)
+		}
+		return codes[i].SwiftCode.SwiftCode < codes[j].SwiftCode.SwiftCode
+	})
+	return codes, nil
+}
+
+// RestoreSWIFTCode clears the tombstone of a soft-deleted SWIFT code and
+// returns the restored record, failing as the PostgreSQL implementation does
+func (m *MemoryStore) RestoreSWIFTCode(ctx context.Context, code string, retention time.Duration) (*models.SwiftCode, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	deleted, ok := m.deleted[code]
+	if !ok {
+		if _, live := m.codes[code]; live {
+			return nil, fmt.Errorf("swift code %q is not deleted: %w", code, ErrAlreadyExists)
+		}
+		return nil, fmt.Errorf("deleted swift code %q: %w", code, ErrNotFound)
+	}
+	deletedAt := sql.NullTime{Time: deleted.DeletedAt, Valid: true}
+	if err := checkRestorable(code, deletedAt, retention, m.now()); err != nil {
+		return nil, err
+	}
+
+	restored := m.put(ctx, deleted.SwiftCode)
+	return &restored, nil
+}
+
+// PurgeDeletedSWIFTCodes permanently removes the SWIFT codes soft-deleted
+// more than olderThan ago and returns how many were removed. Their history
+// is kept.
+func (m *MemoryStore) PurgeDeletedSWIFTCodes(ctx context.Context, olderThan time.Duration) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cutoff := m.now().Add(-olderThan)
+	purged := 0
+	for code, deleted := range m.deleted {
+		if deleted.DeletedAt.Before(cutoff) {
+			delete(m.deleted, code)
+			purged++
+		}
+	}
+	return purged, nil
+}
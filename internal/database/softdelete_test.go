@@ -0,0 +1,146 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRestoreSWIFTCode(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	ctx := WithAudit(context.Background(), Audit{Actor: "alice", Source: SourceAPI})
+	codes := benchmarkCodes(1)
+	cleanupBenchmarkCodes(t, db, codes)
+	defer cleanupBenchmarkCodes(t, db, codes)
+
+	code := codes[0].SwiftCode
+	if err := db.AddSWIFTCode(ctx, &codes[0]); err != nil {
+		t.Fatalf("Failed to add SWIFT code: %v", err)
+	}
+	if err := db.DeleteSWIFTCode(ctx, code, DeleteOptions{}); err != nil {
+		t.Fatalf("Failed to delete SWIFT code: %v", err)
+	}
+
+	restored, err := db.RestoreSWIFTCode(ctx, code, 0)
+	if err != nil {
+		t.Fatalf("Failed to restore SWIFT code: %v", err)
+	}
+	if restored.SwiftCode != code || restored.BankName != codes[0].BankName {
+		t.Errorf("want %s restored with its values, got %+v", code, restored)
+	}
+
+	history, err := db.GetHistory(code)
+	if err != nil {
+		t.Fatalf("Failed to get history: %v", err)
+	}
+	want := []Operation{OperationInsert, OperationDelete, OperationRestore}
+	if len(history) != len(want) {
+		t.Fatalf("want %d history entries, got %d", len(want), len(history))
+	}
+	for i, op := range want {
+		if history[i].Operation != op {
+			t.Errorf("want %s at position %d, got %s", op, i, history[i].Operation)
+		}
+	}
+	if last := history[2]; last.Actor != "alice" || last.NewValues == nil || last.NewValues.SwiftCode != code {
+		t.Errorf("want restore by alice with the new values, got %+v", last)
+	}
+}
+
+func TestAddSWIFTCodeRestoresTombstone(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	ctx := context.Background()
+	codes := benchmarkCodes(1)
+	cleanupBenchmarkCodes(t, db, codes)
+	defer cleanupBenchmarkCodes(t, db, codes)
+
+	code := codes[0]
+	if err := db.AddSWIFTCode(ctx, &code); err != nil {
+		t.Fatalf("Failed to add SWIFT code: %v", err)
+	}
+	if err := db.DeleteSWIFTCode(ctx, code.SwiftCode, DeleteOptions{}); err != nil {
+		t.Fatalf("Failed to delete SWIFT code: %v", err)
+	}
+
+	code.BankName = "RENAMED BANK"
+	if err := db.AddSWIFTCode(ctx, &code); err != nil {
+		t.Fatalf("Failed to add SWIFT code over its tombstone: %v", err)
+	}
+	got, err := db.GetSWIFTCode(code.SwiftCode)
+	if err != nil || got.BankName != "RENAMED BANK" {
+		t.Fatalf("want the code back with the new values, got %+v and %v", got, err)
+	}
+
+	history, err := db.GetHistory(code.SwiftCode)
+	if err != nil {
+		t.Fatalf("Failed to get history: %v", err)
+	}
+	if last := history[len(history)-1]; last.Operation != OperationRestore || last.NewValues.BankName != "RENAMED BANK" {
+		t.Errorf("want restore with the new values recorded, got %+v", last)
+	}
+}
+
+func TestPurgeDeletedSWIFTCodes(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	ctx := context.Background()
+	codes := benchmarkCodes(2)
+	cleanupBenchmarkCodes(t, db, codes)
+	defer cleanupBenchmarkCodes(t, db, codes)
+
+	if err := db.InsertSwiftCodes(ctx, codes); err != nil {
+		t.Fatalf("Failed to insert SWIFT codes: %v", err)
+	}
+	for _, code := range codes {
+		if err := db.DeleteSWIFTCode(ctx, code.SwiftCode, DeleteOptions{}); err != nil {
+			t.Fatalf("Failed to delete SWIFT code: %v", err)
+		}
+	}
+	// The first tombstone is far older than any other in the table
+	old, recent := codes[0].SwiftCode, codes[1].SwiftCode
+	if _, err := db.Exec(`UPDATE swift_codes SET deleted_at = '1900-01-01' WHERE swift_code = $1`, old); err != nil {
+		t.Fatalf("Failed to backdate tombstone: %v", err)
+	}
+
+	deleted, err := db.GetDeletedSWIFTCodes()
+	if err != nil {
+		t.Fatalf("Failed to list deleted codes: %v", err)
+	}
+	listed := make(map[string]bool)
+	for _, code := range deleted {
+		listed[code.SwiftCode.SwiftCode] = true
+	}
+	if !listed[old] || !listed[recent] {
+		t.Errorf("want %s and %s listed as deleted, got %+v", old, recent, deleted)
+	}
+
+	if _, err := db.RestoreSWIFTCode(ctx, old, time.Hour); !errors.Is(err, ErrRetentionExpired) {
+		t.Errorf("want ErrRetentionExpired past retention, got %v", err)
+	}
+	if _, err := db.RestoreSWIFTCode(ctx, codes[0].SwiftCode[:8]+"XXX", 0); !errors.Is(err, ErrNotFound) {
+		t.Errorf("want ErrNotFound for a code never stored, got %v", err)
+	}
+
+	purged, err := db.PurgeDeletedSWIFTCodes(ctx, 100*365*24*time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to purge: %v", err)
+	}
+	if purged != 1 {
+		t.Errorf("want 1 tombstone purged, got %d", purged)
+	}
+	if _, err := db.RestoreSWIFTCode(ctx, old, 0); !errors.Is(err, ErrNotFound) {
+		t.Errorf("want purged code gone, got %v", err)
+	}
+	if _, err := db.RestoreSWIFTCode(ctx, recent, 0); err != nil {
+		t.Errorf("want recent tombstone kept and restorable, got %v", err)
+	}
+	if _, err := db.GetHistory(old); err != nil {
+		t.Errorf("want history kept after purge, got %v", err)
+	}
+}
@@ -0,0 +1,69 @@
+package database
+
+import (
+	"context"
+	"swift-parser/internal/models"
+	"time"
+)
+
+// SwiftCodeStore is the storage contract used by the API. It is implemented
+// by the PostgreSQL-backed DB and by the in-memory MemoryStore. Writes are
+// recorded in the change history with the Audit carried by their context.
+type SwiftCodeStore interface {
+	GetSWIFTCode(code string) (*models.SwiftCode, error)
+	GetBranches(headquarterCode string) ([]models.SwiftCode, error)
+	GetSWIFTCodesByCountry(countryISO2 string, q CountryQuery) ([]models.SwiftCode, error)
+	GetSWIFTCodesByPrefix(prefix string) ([]models.SwiftCode, error)
+	ListSWIFTCodes() ([]models.SwiftCode, error)
+	ExportSWIFTCodes(ctx context.Context, q ExportQuery, fn func(models.SwiftCode) error) error
+	SearchSWIFTCodes(q SearchQuery) ([]SearchResult, error)
+	GetHistory(code string) ([]HistoryEntry, error)
+	GetSWIFTCodeAsOf(code string, asOf time.Time) (*models.SwiftCode, error)
+	GetBranchesAsOf(headquarterCode string, asOf time.Time) ([]models.SwiftCode, error)
+	AddSWIFTCode(ctx context.Context, code *models.SwiftCode) error
+	UpdateSWIFTCode(ctx context.Context, code *models.SwiftCode, opts UpdateOptions) error
+	DeleteSWIFTCode(ctx context.Context, code string, opts DeleteOptions) error
+	GetDeletedSWIFTCodes() ([]DeletedSwiftCode, error)
+	RestoreSWIFTCode(ctx context.Context, code string, retention time.Duration) (*models.SwiftCode, error)
+	PurgeDeletedSWIFTCodes(ctx context.Context, olderThan time.Duration) (int, error)
+	InsertSwiftCodes(ctx context.Context, codes []models.SwiftCode) error
+	SyncSWIFTCodes(ctx context.Context, codes []models.SwiftCode, opts SyncOptions) (SyncResult, error)
+}
+
+// UpdateOptions configures UpdateSWIFTCode
+type UpdateOptions struct {
+	// IfVersion makes the update conditional on the stored version. Zero
+	// updates unconditionally.
+	IfVersion int64
+}
+
+// DeleteOptions configures DeleteSWIFTCode
+type DeleteOptions struct {
+	// IfVersion makes the delete conditional on the stored version. Zero
+	// deletes unconditionally.
+	IfVersion int64
+	// Branches decides what happens to the branches of a deleted
+	// headquarter, as returned by GetBranches. It is ignored for branches.
+	Branches BranchPolicy
+}
+
+// BranchPolicy is applied to the branches of a headquarter being deleted
+type BranchPolicy string
+
+const (
+	// BranchesReject fails with a BranchesExistError if the headquarter
+	// has branches. It is the default.
+	BranchesReject BranchPolicy = "reject"
+	// BranchesCascade deletes the branches with the headquarter
+	BranchesCascade BranchPolicy = "cascade"
+	// BranchesDetach deletes only the headquarter, leaving its branches
+	// without one
+	BranchesDetach BranchPolicy = "detach"
+)
+
+var (
+	_ SwiftCodeStore = (*DB)(nil)
+	_ SwiftCodeStore = (*MemoryStore)(nil)
+	_ ImportJobStore = (*DB)(nil)
+	_ ImportJobStore = (*MemoryStore)(nil)
+)
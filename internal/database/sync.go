@@ -0,0 +1,210 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"swift-parser/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// DefaultMaxRemovedPercent is the share of the codes in scope a sync may
+// remove unless configured otherwise
+const DefaultMaxRemovedPercent = 10
+
+func removedPercent(removed, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(removed) * 100 / float64(total)
+}
+
+// SyncOptions configures SyncSWIFTCodes
+type SyncOptions struct {
+	// Countries limits the sync to codes of these ISO2 countries. Codes of
+	// other countries are neither written nor removed. Empty syncs the
+	// whole table.
+	Countries []string
+	// MaxRemovedPercent refuses a sync that would remove more than this
+	// percentage of the stored codes in scope
+	MaxRemovedPercent float64
+	// Keep lists codes present in the source that were not written, such
+	// as those of rejected rows. They are never removed.
+	Keep []string
+}
+
+// InScope reports whether code belongs to the scope of the sync
+func (o SyncOptions) InScope(code models.SwiftCode) bool {
+	if len(o.Countries) == 0 {
+		return true
+	}
+	for _, country := range o.Countries {
+		if code.CountryISO2 == country {
+			return true
+		}
+	}
+	return false
+}
+
+// check fails with a SyncThresholdError if removing removed of total codes
+// exceeds the threshold
+func (o SyncOptions) check(removed, total int) error {
+	if removed > 0 && removedPercent(removed, total) > o.MaxRemovedPercent {
+		return &SyncThresholdError{Removed: removed, Total: total, MaxPercent: o.MaxRemovedPercent}
+	}
+	return nil
+}
+
+// SyncResult is the outcome of SyncSWIFTCodes
+type SyncResult struct {
+	// Upserted counts the codes in scope that were written
+	Upserted int
+	// OutOfScope counts the codes left out because of their country
+	OutOfScope int
+	// Removed lists the stored codes missing from the input, ordered by
+	// code
+	Removed []string
+}
+
+// scopeCodes splits codes into those in scope and the number left out
+func scopeCodes(codes []models.SwiftCode, opts SyncOptions) ([]models.SwiftCode, int) {
+	scoped := make([]models.SwiftCode, 0, len(codes))
+	for _, code := range codes {
+		if opts.InScope(code) {
+			scoped = append(scoped, code)
+		}
+	}
+	return scoped, len(codes) - len(scoped)
+}
+
+// SyncSWIFTCodes makes the stored codes in scope match codes in one
+// transaction: codes are upserted and stored codes missing from them are
+// soft-deleted, regardless of their branches. It fails with a
+// SyncThresholdError without writing anything if too many would be removed.
+func (db *DB) SyncSWIFTCodes(ctx context.Context, codes []models.SwiftCode, opts SyncOptions) (SyncResult, error) {
+	scoped, outOfScope := scopeCodes(codes, opts)
+	result := SyncResult{OutOfScope: outOfScope}
+
+	keep := make([]string, 0, len(scoped)+len(opts.Keep))
+	for _, code := range scoped {
+		keep = append(keep, code.SwiftCode)
+	}
+	keep = append(keep, opts.Keep...)
+
+	tx, err := db.beginAudited(ctx)
+	if err != nil {
+		return result, err
+	}
+	defer tx.Rollback()
+
+	var args []any
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	scope := `deleted_at IS NULL`
+	if len(opts.Countries) > 0 {
+		scope += ` AND country_iso2 = ANY(` + arg(pq.Array(opts.Countries)) + `)`
+	}
+
+	var total int
+	if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM swift_codes WHERE `+scope, args...).Scan(&total); err != nil {
+		return result, err
+	}
+
+	query := `
+        SELECT swift_code FROM swift_codes
+        WHERE ` + scope + `
+        AND swift_code <> ALL(` + arg(pq.Array(keep)) + `)
+        ORDER BY swift_code COLLATE "C"
+        FOR UPDATE`
+
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return result, err
+	}
+	var removed []string
+	for rows.Next() {
+		var code string
+		if err := rows.Scan(&code); err != nil {
+			rows.Close()
+			return result, err
+		}
+		removed = append(removed, code)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return result, err
+	}
+	if err := opts.check(len(removed), total); err != nil {
+		return result, err
+	}
+
+	if err := upsertSwiftCodes(ctx, tx, scoped); err != nil {
+		return result, err
+	}
+	if len(removed) > 0 {
+		_, err := tx.ExecContext(ctx, `
+            UPDATE swift_codes SET deleted_at = CURRENT_TIMESTAMP
+            WHERE swift_code = ANY($1)`, pq.Array(removed))
+		if err != nil {
+			return result, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return result, err
+	}
+
+	result.Upserted = len(scoped)
+	result.Removed = removed
+	return result, nil
+}
+
+// SyncSWIFTCodes makes the stored codes in scope match codes, as the
+// PostgreSQL implementation does
+func (m *MemoryStore) SyncSWIFTCodes(ctx context.Context, codes []models.SwiftCode, opts SyncOptions) (SyncResult, error) {
+	scoped, outOfScope := scopeCodes(codes, opts)
+	result := SyncResult{OutOfScope: outOfScope}
+	if err := ctx.Err(); err != nil {
+		return result, err
+	}
+
+	keep := make(map[string]bool, len(scoped)+len(opts.Keep))
+	for _, code := range scoped {
+		keep[code.SwiftCode] = true
+	}
+	for _, code := range opts.Keep {
+		keep[code] = true
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	total := 0
+	var removed []string
+	for _, code := range m.codes {
+		if !opts.InScope(code) {
+			continue
+		}
+		total++
+		if !keep[code.SwiftCode] {
+			removed = append(removed, code.SwiftCode)
+		}
+	}
+	if err := opts.check(len(removed), total); err != nil {
+		return result, err
+	}
+
+	for _, code := range scoped {
+		m.put(ctx, code)
+	}
+	sort.Strings(removed)
+	for _, code := range removed {
+		m.softDelete(ctx, m.codes[code])
+	}
+
+	result.Upserted = len(scoped)
+	result.Removed = removed
+	return result, nil
+}
@@ -0,0 +1,92 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"swift-parser/internal/models"
+	"testing"
+)
+
+// antarcticCodes turns generated codes into codes of AQ, a country without
+// any real codes, so a sync scoped to it only sees fixtures
+func antarcticCodes(codes []models.SwiftCode) []models.SwiftCode {
+	for i := range codes {
+		codes[i].SwiftCode = strings.Replace(codes[i].SwiftCode, "PL", "AQ", 1)
+		codes[i].CountryISO2 = "AQ"
+		codes[i].CountryName = "ANTARCTICA"
+	}
+	return codes
+}
+
+func TestSyncSWIFTCodes(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	ctx := context.Background()
+	codes := antarcticCodes(benchmarkCodes(4))
+	outOfScope := benchmarkCodes(1)[0]
+	fixtures := append(append([]models.SwiftCode{}, codes...), outOfScope)
+	cleanupBenchmarkCodes(t, db, fixtures)
+	defer cleanupBenchmarkCodes(t, db, fixtures)
+
+	if err := db.InsertSwiftCodes(ctx, codes[:3]); err != nil {
+		t.Fatalf("Failed to insert SWIFT codes: %v", err)
+	}
+
+	// codes[1] is missing from the source and codes[2] is on a rejected row
+	renamed := codes[0]
+	renamed.BankName = "RENAMED BANK"
+	source := []models.SwiftCode{renamed, codes[3], outOfScope}
+	opts := SyncOptions{
+		Countries:         []string{"AQ"},
+		MaxRemovedPercent: 10,
+		Keep:              []string{codes[2].SwiftCode},
+	}
+
+	if _, err := db.SyncSWIFTCodes(ctx, source, opts); !errors.Is(err, ErrSyncThreshold) {
+		t.Fatalf("want ErrSyncThreshold removing 1 of 3 codes, got %v", err)
+	}
+	if _, err := db.GetSWIFTCode(codes[3].SwiftCode); !errors.Is(err, ErrNotFound) {
+		t.Errorf("want nothing written by a refused sync, got %v", err)
+	}
+
+	opts.MaxRemovedPercent = 100
+	result, err := db.SyncSWIFTCodes(ctx, source, opts)
+	if err != nil {
+		t.Fatalf("Failed to sync: %v", err)
+	}
+	if result.Upserted != 2 || result.OutOfScope != 1 ||
+		len(result.Removed) != 1 || result.Removed[0] != codes[1].SwiftCode {
+		t.Errorf("want 2 upserted, 1 out of scope and %s removed, got %+v", codes[1].SwiftCode, result)
+	}
+
+	if got, err := db.GetSWIFTCode(codes[0].SwiftCode); err != nil || got.BankName != "RENAMED BANK" {
+		t.Errorf("want %s renamed, got %+v and %v", codes[0].SwiftCode, got, err)
+	}
+	if _, err := db.GetSWIFTCode(codes[1].SwiftCode); !errors.Is(err, ErrNotFound) {
+		t.Errorf("want %s removed, got %v", codes[1].SwiftCode, err)
+	}
+	if _, err := db.GetSWIFTCode(codes[2].SwiftCode); err != nil {
+		t.Errorf("want kept %s left alone, got %v", codes[2].SwiftCode, err)
+	}
+	if _, err := db.GetSWIFTCode(outOfScope.SwiftCode); !errors.Is(err, ErrNotFound) {
+		t.Errorf("want out of scope %s left out, got %v", outOfScope.SwiftCode, err)
+	}
+
+	// Syncing the removed code back restores it
+	result, err = db.SyncSWIFTCodes(ctx, codes, opts)
+	if err != nil {
+		t.Fatalf("Failed to sync: %v", err)
+	}
+	if len(result.Removed) != 0 {
+		t.Errorf("want nothing removed, got %v", result.Removed)
+	}
+	history, err := db.GetHistory(codes[1].SwiftCode)
+	if err != nil {
+		t.Fatalf("Failed to get history: %v", err)
+	}
+	if last := history[len(history)-1]; last.Operation != OperationRestore {
+		t.Errorf("want %s restored, got %s", codes[1].SwiftCode, last.Operation)
+	}
+}
@@ -0,0 +1,194 @@
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"swift-parser/internal/models"
+)
+
+// Kind classifies how a SWIFT code differs between the stored directory and
+// an incoming one
+type Kind string
+
+const (
+	Added     Kind = "added"
+	Removed   Kind = "removed"
+	Modified  Kind = "modified"
+	Unchanged Kind = "unchanged"
+)
+
+// FieldChange is a field whose value differs, named as in the API
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// Change is the classification of a single SWIFT code. Fields lists the
+// changed fields of a modified code. Old is nil for added codes and New is
+// nil for removed ones.
+type Change struct {
+	SwiftCode string            `json:"swiftCode"`
+	Kind      Kind              `json:"kind"`
+	Fields    []FieldChange     `json:"fields,omitempty"`
+	Old       *models.SwiftCode `json:"-"`
+	New       *models.SwiftCode `json:"-"`
+}
+
+// Result is the outcome of Compare, ordered by code
+type Result struct {
+	Changes []Change `json:"changes"`
+}
+
+// Compare classifies every code in current or incoming. Codes are matched
+// by SWIFT code; versions are ignored.
+func Compare(current, incoming []models.SwiftCode) *Result {
+	stored := make(map[string]models.SwiftCode, len(current))
+	for _, code := range current {
+		stored[code.SwiftCode] = code
+	}
+
+	result := &Result{Changes: make([]Change, 0, len(incoming))}
+	seen := make(map[string]bool, len(incoming))
+	for i := range incoming {
+		code := &incoming[i]
+		seen[code.SwiftCode] = true
+
+		old, ok := stored[code.SwiftCode]
+		if !ok {
+			result.Changes = append(result.Changes, Change{SwiftCode: code.SwiftCode, Kind: Added, New: code})
+			continue
+		}
+
+		change := Change{SwiftCode: code.SwiftCode, Kind: Unchanged, Old: &old, New: code}
+		if change.Fields = compareFields(old, *code); len(change.Fields) > 0 {
+			change.Kind = Modified
+		}
+		result.Changes = append(result.Changes, change)
+	}
+	for i := range current {
+		if code := &current[i]; !seen[code.SwiftCode] {
+			result.Changes = append(result.Changes, Change{SwiftCode: code.SwiftCode, Kind: Removed, Old: code})
+		}
+	}
+
+	sort.Slice(result.Changes, func(i, j int) bool {
+		return result.Changes[i].SwiftCode < result.Changes[j].SwiftCode
+	})
+	return result
+}
+
+// compareFields lists the fields that differ between old and new
+func compareFields(old, new models.SwiftCode) []FieldChange {
+	var fields []FieldChange
+	add := func(field, oldValue, newValue string) {
+		if oldValue != newValue {
+			fields = append(fields, FieldChange{Field: field, Old: oldValue, New: newValue})
+		}
+	}
+	add("countryISO2", old.CountryISO2, new.CountryISO2)
+	add("countryName", old.CountryName, new.CountryName)
+	add("bankName", old.BankName, new.BankName)
+	add("address", old.Address, new.Address)
+	add("isHeadquarter", strconv.FormatBool(old.IsHeadquarter), strconv.FormatBool(new.IsHeadquarter))
+	return fields
+}
+
+// Count returns the number of codes of the given kind
+func (r *Result) Count(kind Kind) int {
+	n := 0
+	for _, change := range r.Changes {
+		if change.Kind == kind {
+			n++
+		}
+	}
+	return n
+}
+
+// Without returns a copy of r leaving out the codes of the given kinds
+func (r *Result) Without(kinds ...Kind) *Result {
+	filtered := &Result{Changes: make([]Change, 0, len(r.Changes))}
+	for _, change := range r.Changes {
+		if !hasKind(kinds, change.Kind) {
+			filtered.Changes = append(filtered.Changes, change)
+		}
+	}
+	return filtered
+}
+
+func hasKind(kinds []Kind, kind Kind) bool {
+	for _, k := range kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// Summary returns a one-line count of codes of each kind
+func (r *Result) Summary() string {
+	return fmt.Sprintf("%d added, %d removed, %d modified, %d unchanged",
+		r.Count(Added), r.Count(Removed), r.Count(Modified), r.Count(Unchanged))
+}
+
+// WriteText writes one line per changed code to w, marking added codes
+// with +, removed codes with - and modified codes with ~ followed by their
+// changed fields, then the summary. Unchanged codes are listed with = when
+// all is set.
+func (r *Result) WriteText(w io.Writer, all bool) error {
+	for _, change := range r.Changes {
+		var err error
+		switch change.Kind {
+		case Added:
+			_, err = fmt.Fprintf(w, "+ %s\n", change.SwiftCode)
+		case Removed:
+			_, err = fmt.Fprintf(w, "- %s\n", change.SwiftCode)
+		case Modified:
+			if _, err = fmt.Fprintf(w, "~ %s\n", change.SwiftCode); err != nil {
+				return err
+			}
+			for _, field := range change.Fields {
+				if _, err = fmt.Fprintf(w, "    %s: %q -> %q\n", field.Field, field.Old, field.New); err != nil {
+					return err
+				}
+			}
+		case Unchanged:
+			if all {
+				_, err = fmt.Fprintf(w, "= %s\n", change.SwiftCode)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintln(w, r.Summary())
+	return err
+}
+
+// WriteJSON writes the count of each kind and the changed codes to w as an
+// indented JSON object. Unchanged codes are listed too when all is set.
+func (r *Result) WriteJSON(w io.Writer, all bool) error {
+	changes := r
+	if !all {
+		changes = r.Without(Unchanged)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(struct {
+		Added     int      `json:"added"`
+		Removed   int      `json:"removed"`
+		Modified  int      `json:"modified"`
+		Unchanged int      `json:"unchanged"`
+		Changes   []Change `json:"changes"`
+	}{
+		Added:     r.Count(Added),
+		Removed:   r.Count(Removed),
+		Modified:  r.Count(Modified),
+		Unchanged: r.Count(Unchanged),
+		Changes:   changes.Changes,
+	})
+}
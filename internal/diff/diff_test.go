@@ -0,0 +1,93 @@
+package diff
+
+import (
+	"strings"
+	"swift-parser/internal/models"
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	current := []models.SwiftCode{
+		{SwiftCode: "TESTTR00XXX", CountryISO2: "TR", CountryName: "TURKEY", BankName: "TEST BANK", IsHeadquarter: true, Version: 3},
+		{SwiftCode: "TESTTR00001", CountryISO2: "TR", CountryName: "TURKEY", BankName: "TEST BANK", Address: "ANKARA"},
+		{SwiftCode: "OLDBTR00XXX", CountryISO2: "TR", CountryName: "TURKEY", BankName: "OLD BANK", IsHeadquarter: true},
+	}
+	incoming := []models.SwiftCode{
+		{SwiftCode: "TESTTR00XXX", CountryISO2: "TR", CountryName: "TURKEY", BankName: "TEST BANK", IsHeadquarter: true},
+		{SwiftCode: "TESTTR00001", CountryISO2: "TR", CountryName: "TURKEY", BankName: "RENAMED BANK", Address: "ISTANBUL"},
+		{SwiftCode: "NEWBTR00XXX", CountryISO2: "TR", CountryName: "TURKEY", BankName: "NEW BANK", IsHeadquarter: true},
+	}
+
+	result := Compare(current, incoming)
+
+	want := map[string]Kind{
+		"NEWBTR00XXX": Added,
+		"OLDBTR00XXX": Removed,
+		"TESTTR00001": Modified,
+		"TESTTR00XXX": Unchanged,
+	}
+	if len(result.Changes) != len(want) {
+		t.Fatalf("want %d changes, got %+v", len(want), result.Changes)
+	}
+	for i, change := range result.Changes {
+		if i > 0 && result.Changes[i-1].SwiftCode >= change.SwiftCode {
+			t.Errorf("want changes ordered by code, got %s after %s", change.SwiftCode, result.Changes[i-1].SwiftCode)
+		}
+		if change.Kind != want[change.SwiftCode] {
+			t.Errorf("%s: want %s, got %s", change.SwiftCode, want[change.SwiftCode], change.Kind)
+		}
+	}
+
+	modified := result.Changes[2]
+	if len(modified.Fields) != 2 || modified.Fields[0] != (FieldChange{"bankName", "TEST BANK", "RENAMED BANK"}) ||
+		modified.Fields[1].Field != "address" {
+		t.Errorf("want bankName and address changed, got %+v", modified.Fields)
+	}
+
+	if got := result.Summary(); got != "1 added, 1 removed, 1 modified, 1 unchanged" {
+		t.Errorf("unexpected summary %q", got)
+	}
+	if upserts := result.Without(Removed, Unchanged); len(upserts.Changes) != 2 {
+		t.Errorf("want 2 changes without removed and unchanged codes, got %+v", upserts.Changes)
+	}
+}
+
+func TestWriteText(t *testing.T) {
+	result := Compare(
+		[]models.SwiftCode{{SwiftCode: "TESTTR00XXX", BankName: "TEST BANK"}, {SwiftCode: "OLDBTR00XXX"}},
+		[]models.SwiftCode{{SwiftCode: "TESTTR00XXX", BankName: "NEW NAME"}, {SwiftCode: "NEWBTR00XXX"}},
+	)
+
+	var out strings.Builder
+	if err := result.WriteText(&out, false); err != nil {
+		t.Fatalf("Failed to write text: %v", err)
+	}
+	want := "+ NEWBTR00XXX\n" +
+		"- OLDBTR00XXX\n" +
+		"~ TESTTR00XXX\n" +
+		"    bankName: \"TEST BANK\" -> \"NEW NAME\"\n" +
+		"1 added, 1 removed, 1 modified, 0 unchanged\n"
+	if out.String() != want {
+		t.Errorf("want:\n%s\ngot:\n%s", want, out.String())
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	result := Compare(
+		[]models.SwiftCode{{SwiftCode: "TESTTR00XXX"}, {SwiftCode: "SAMETR00XXX"}},
+		[]models.SwiftCode{{SwiftCode: "TESTTR00XXX", BankName: "NEW NAME"}, {SwiftCode: "SAMETR00XXX"}},
+	)
+
+	var out strings.Builder
+	if err := result.WriteJSON(&out, false); err != nil {
+		t.Fatalf("Failed to write JSON: %v", err)
+	}
+	for _, want := range []string{`"modified": 1`, `"unchanged": 1`, `"field": "bankName"`} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("want %s in output:\n%s", want, out.String())
+		}
+	}
+	if strings.Contains(out.String(), `"swiftCode": "SAMETR00XXX"`) {
+		t.Errorf("want unchanged codes left out without all:\n%s", out.String())
+	}
+}
@@ -0,0 +1,305 @@
+package importer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"swift-parser/internal/database"
+	"swift-parser/internal/models"
+	"swift-parser/internal/parser"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DefaultWorkers is the number of jobs processed concurrently
+	DefaultWorkers = 2
+	// DefaultBatchSize is the number of codes written per transaction
+	DefaultBatchSize = 1000
+	// MaxAttempts is how many times a job is started before a restart
+	// fails it instead of resuming it
+	MaxAttempts = 3
+	// maxErrors caps the rejected rows recorded on a job
+	maxErrors = 100
+	// pollInterval is how often idle workers look for jobs queued by
+	// other servers
+	pollInterval = 5 * time.Second
+	// heartbeatInterval is how often a running job renews its lease
+	heartbeatInterval = 30 * time.Second
+	// DefaultStaleAfter is how long a running job may go without a
+	// heartbeat before it is considered abandoned by its server
+	DefaultStaleAfter = 2 * time.Minute
+)
+
+// errStopped is returned while processing a job that was canceled
+var errStopped = errors.New("import job is no longer running")
+
+// Runner processes import jobs in the background with a bounded pool of
+// workers. Jobs and their files are persisted in an ImportJobStore, so a
+// job whose server stopped is resumed by any runner sharing the store, or
+// failed once it has been interrupted MaxAttempts times. Running jobs
+// send heartbeats so that jobs of live servers are never taken over.
+type Runner struct {
+	codes      database.SwiftCodeStore
+	jobs       database.ImportJobStore
+	owner      string
+	workers    int
+	batchSize  int
+	staleAfter time.Duration
+	wake       chan struct{}
+
+	mu      sync.Mutex
+	running map[int64]context.CancelFunc
+}
+
+// NewRunner returns a Runner writing to codes and tracking jobs in jobs
+// with the given number of workers
+func NewRunner(codes database.SwiftCodeStore, jobs database.ImportJobStore, workers int) *Runner {
+	if workers <= 0 {
+		workers = DefaultWorkers
+	}
+	return &Runner{
+		codes:      codes,
+		jobs:       jobs,
+		owner:      runnerID(),
+		workers:    workers,
+		batchSize:  DefaultBatchSize,
+		staleAfter: DefaultStaleAfter,
+		wake:       make(chan struct{}, workers),
+		running:    make(map[int64]context.CancelFunc),
+	}
+}
+
+// runnerID identifies this process as the owner of the jobs it claims
+func runnerID() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s:%d", host, os.Getpid())
+}
+
+// Run processes queued jobs until ctx is done, recovering the jobs of
+// stopped servers at start and then every staleAfter. Jobs interrupted by
+// ctx stay running and are recovered once their heartbeat is stale.
+func (r *Runner) Run(ctx context.Context) error {
+	if err := r.recover(ctx); err != nil {
+		return fmt.Errorf("failed to recover import jobs: %w", err)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(max(r.staleAfter, heartbeatInterval))
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := r.recover(ctx); err != nil && ctx.Err() == nil {
+					log.Printf("⚠️ Failed to recover import jobs: %v", err)
+				}
+			}
+		}
+	}()
+	for i := 0; i < r.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.work(ctx)
+		}()
+	}
+	wg.Wait()
+	return nil
+}
+
+// recover requeues the jobs whose heartbeat is stale and wakes the workers
+func (r *Runner) recover(ctx context.Context) error {
+	requeued, failed, err := r.jobs.RecoverImportJobs(ctx, MaxAttempts, r.staleAfter)
+	if err != nil {
+		return err
+	}
+	if requeued > 0 || failed > 0 {
+		log.Printf("Recovered interrupted import jobs: %d requeued, %d failed", requeued, failed)
+	}
+	for i := 0; i < requeued; i++ {
+		select {
+		case r.wake <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// Submit queues an import of the file content payload on behalf of actor
+func (r *Runner) Submit(ctx context.Context, fileName, actor string, payload []byte) (*database.ImportJob, error) {
+	job := &database.ImportJob{FileName: fileName, Actor: actor}
+	if err := r.jobs.CreateImportJob(ctx, job, payload); err != nil {
+		return nil, err
+	}
+
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+	return job, nil
+}
+
+// Job returns the current state of a job
+func (r *Runner) Job(id int64) (*database.ImportJob, error) {
+	return r.jobs.GetImportJob(id)
+}
+
+// Cancel cancels a queued or running job. A job running on this server is
+// stopped at once; one running elsewhere stops at its next batch.
+func (r *Runner) Cancel(ctx context.Context, id int64) (*database.ImportJob, error) {
+	job, err := r.jobs.CancelImportJob(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	if cancel, ok := r.running[id]; ok {
+		cancel()
+	}
+	r.mu.Unlock()
+	return job, nil
+}
+
+// work claims and processes jobs until ctx is done
+func (r *Runner) work(ctx context.Context) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		job, payload, err := r.jobs.ClaimImportJob(ctx, r.owner)
+		if err == nil {
+			r.process(ctx, job, payload)
+			continue
+		}
+		if !errors.Is(err, database.ErrNotFound) && ctx.Err() == nil {
+			log.Printf("⚠️ Failed to claim import job: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-r.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// process imports the payload of a claimed job in batches, recording
+// progress after each one and renewing its lease in between, and stores
+// the outcome
+func (r *Runner) process(ctx context.Context, job *database.ImportJob, payload []byte) {
+	jobCtx, cancel := context.WithCancel(ctx)
+	r.mu.Lock()
+	r.running[job.ID] = cancel
+	r.mu.Unlock()
+	defer func() {
+		r.mu.Lock()
+		delete(r.running, job.ID)
+		r.mu.Unlock()
+		cancel()
+	}()
+
+	var imported atomic.Int64
+	go r.heartbeat(jobCtx, job.ID, &imported, cancel)
+
+	writeCtx := database.WithAudit(jobCtx, database.Audit{
+		Actor:  job.Actor,
+		Source: database.ImportSource(job.FileName),
+	})
+
+	batch := make([]models.SwiftCode, 0, r.batchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := r.codes.InsertSwiftCodes(writeCtx, batch); err != nil {
+			return err
+		}
+		job.Imported += len(batch)
+		imported.Store(int64(job.Imported))
+		batch = batch[:0]
+
+		err := r.jobs.UpdateImportJobProgress(jobCtx, job.ID, job.Imported)
+		if errors.Is(err, database.ErrNotFound) {
+			return errStopped
+		}
+		return err
+	}
+
+	report, err := parser.IterateReader(jobCtx, bytes.NewReader(payload), job.FileName, parser.Options{},
+		func(code models.SwiftCode) error {
+			batch = append(batch, code)
+			if len(batch) < r.batchSize {
+				return nil
+			}
+			return flush()
+		})
+	if err == nil {
+		err = flush()
+	}
+
+	switch {
+	case ctx.Err() != nil:
+		// Shutting down: the job stays running and is resumed on restart
+		return
+	case jobCtx.Err() != nil, errors.Is(err, errStopped):
+		log.Printf("Import job %d canceled after %d codes", job.ID, job.Imported)
+		return
+	}
+
+	job.Status = database.JobSucceeded
+	if err != nil {
+		job.Status = database.JobFailed
+		job.Error = err.Error()
+	}
+	job.Accepted = report.AcceptedCount
+	job.Skipped = len(report.Skipped)
+	job.Rejected = len(report.Rejected)
+	job.Errors = nil
+	for _, issue := range report.Rejected {
+		if len(job.Errors) == maxErrors {
+			break
+		}
+		job.Errors = append(job.Errors, issue.String())
+	}
+
+	if err := r.jobs.FinishImportJob(ctx, job); err != nil && !errors.Is(err, database.ErrNotFound) {
+		log.Printf("⚠️ Failed to record outcome of import job %d: %v", job.ID, err)
+	}
+}
+
+// heartbeat renews the lease of a running job until ctx is done, calling
+// stop once the job is no longer running, for example because it was
+// canceled on another server
+func (r *Runner) heartbeat(ctx context.Context, id int64, imported *atomic.Int64, stop context.CancelFunc) {
+	ticker := time.NewTicker(heartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		err := r.jobs.UpdateImportJobProgress(ctx, id, int(imported.Load()))
+		if errors.Is(err, database.ErrNotFound) {
+			stop()
+			return
+		}
+		if err != nil && ctx.Err() == nil {
+			log.Printf("⚠️ Failed to renew import job %d: %v", id, err)
+		}
+	}
+}
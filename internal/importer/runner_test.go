@@ -0,0 +1,132 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"swift-parser/internal/database"
+	"testing"
+	"time"
+)
+
+const testCSV = "SWIFT CODE,COUNTRY ISO2 CODE,NAME,ADDRESS,COUNTRY NAME\n" +
+	"TESTTR05XXX,TR,TEST BANK,ISTANBUL,TURKEY\n" +
+	"TESTTR05001,TR,TEST BANK,ANKARA,TURKEY\n" +
+	"TESTXX05XXX,XX,BAD BANK,,NOWHERE\n"
+
+// waitForJob polls job id until it finishes
+func waitForJob(t *testing.T, r *Runner, id int64) *database.ImportJob {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		job, err := r.Job(id)
+		if err != nil {
+			t.Fatalf("Failed to get job: %v", err)
+		}
+		if job.Status.Finished() {
+			return job
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("job %d did not finish", id)
+	return nil
+}
+
+func TestRunnerImportsJob(t *testing.T) {
+	store := database.NewMemoryStore()
+	runner := NewRunner(store, store, 2)
+	runner.batchSize = 1
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go runner.Run(ctx)
+
+	job, err := runner.Submit(ctx, "codes.csv", "ops", []byte(testCSV))
+	if err != nil {
+		t.Fatalf("Failed to submit job: %v", err)
+	}
+
+	job = waitForJob(t, runner, job.ID)
+	if job.Status != database.JobSucceeded || job.Imported != 2 || job.Accepted != 2 || job.Rejected != 1 {
+		t.Errorf("want 2 codes imported and 1 row rejected, got %+v", job)
+	}
+	if len(job.Errors) != 1 {
+		t.Errorf("want the rejected row listed, got %v", job.Errors)
+	}
+
+	history, err := store.GetHistory("TESTTR05001")
+	if err != nil || history[0].Actor != "ops" || history[0].Source != "import:codes.csv" {
+		t.Errorf("want import attributed to ops from import:codes.csv, got %+v, %v", history, err)
+	}
+
+	if _, err := runner.Cancel(ctx, job.ID); !errors.Is(err, database.ErrJobFinished) {
+		t.Errorf("want ErrJobFinished canceling a finished job, got %v", err)
+	}
+}
+
+func TestRunnerFailsUnparseableJob(t *testing.T) {
+	store := database.NewMemoryStore()
+	runner := NewRunner(store, store, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go runner.Run(ctx)
+
+	job, err := runner.Submit(ctx, "codes.csv", "ops", []byte("CODE,NAME\nX,Y\n"))
+	if err != nil {
+		t.Fatalf("Failed to submit job: %v", err)
+	}
+	if job = waitForJob(t, runner, job.ID); job.Status != database.JobFailed || job.Error == "" {
+		t.Errorf("want job failed with an error, got %+v", job)
+	}
+}
+
+func TestRunnerRecoversInterruptedJobs(t *testing.T) {
+	ctx := context.Background()
+	store := database.NewMemoryStore()
+
+	// Simulate servers that stopped mid-job: the first job on every
+	// attempt, the second once
+	exhausted := &database.ImportJob{FileName: "crash.csv"}
+	if err := store.CreateImportJob(ctx, exhausted, []byte(testCSV)); err != nil {
+		t.Fatalf("Failed to create job: %v", err)
+	}
+	for i := 0; i < MaxAttempts; i++ {
+		if i > 0 {
+			store.RecoverImportJobs(ctx, MaxAttempts, 0)
+		}
+		if _, _, err := store.ClaimImportJob(ctx, "stopped"); err != nil {
+			t.Fatalf("Failed to claim job: %v", err)
+		}
+	}
+	resumed := &database.ImportJob{FileName: "codes.csv"}
+	if err := store.CreateImportJob(ctx, resumed, []byte(testCSV)); err != nil {
+		t.Fatalf("Failed to create job: %v", err)
+	}
+	if _, _, err := store.ClaimImportJob(ctx, "stopped"); err != nil {
+		t.Fatalf("Failed to claim job: %v", err)
+	}
+	canceled := &database.ImportJob{FileName: "canceled.csv"}
+	if err := store.CreateImportJob(ctx, canceled, []byte(testCSV)); err != nil {
+		t.Fatalf("Failed to create job: %v", err)
+	}
+
+	runner := NewRunner(store, store, 1)
+	runner.staleAfter = 0
+	if _, err := runner.Cancel(ctx, canceled.ID); err != nil {
+		t.Fatalf("Failed to cancel queued job: %v", err)
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go runner.Run(runCtx)
+
+	if job := waitForJob(t, runner, resumed.ID); job.Status != database.JobSucceeded || job.Attempts != 2 {
+		t.Errorf("want interrupted job resumed on its second attempt, got %+v", job)
+	}
+	if job := waitForJob(t, runner, exhausted.ID); job.Status != database.JobFailed {
+		t.Errorf("want job interrupted %d times failed, got %+v", MaxAttempts, job)
+	}
+	if job := waitForJob(t, runner, canceled.ID); job.Status != database.JobCanceled || job.Imported != 0 {
+		t.Errorf("want canceled job left unprocessed, got %+v", job)
+	}
+}
@@ -7,4 +7,8 @@ type SwiftCode struct {
 	CountryName   string
 	IsHeadquarter bool
 	SwiftCode     string
+
+	// Version increases each time the stored values change. It is set by
+	// the store and exposed as the ETag header rather than in JSON bodies.
+	Version int64 `json:"-"`
 }
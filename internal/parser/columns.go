@@ -0,0 +1,148 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"swift-parser/internal/models"
+)
+
+// Column identifies a SWIFT code field read from the source spreadsheet
+type Column string
+
+const (
+	ColumnSwiftCode   Column = "swiftCode"
+	ColumnCountryISO2 Column = "countryISO2"
+	ColumnCountryName Column = "countryName"
+	ColumnBankName    Column = "bankName"
+	ColumnAddress     Column = "address"
+)
+
+// ErrMissingColumn is returned when the header row lacks a required column
+var ErrMissingColumn = errors.New("required column missing")
+
+// DefaultAliases lists the header names accepted for each column. Header
+// cells are matched case-insensitively with surrounding spaces ignored.
+var DefaultAliases = map[Column][]string{
+	ColumnSwiftCode:   {"SWIFT CODE", "SWIFT", "BIC", "BIC CODE", "SWIFT/BIC"},
+	ColumnCountryISO2: {"COUNTRY ISO2 CODE", "COUNTRY ISO2", "ISO2", "COUNTRY CODE"},
+	ColumnCountryName: {"COUNTRY NAME", "COUNTRY"},
+	ColumnBankName:    {"NAME", "BANK NAME", "INSTITUTION NAME"},
+	ColumnAddress:     {"ADDRESS", "BANK ADDRESS"},
+}
+
+// requiredColumns must be present in the header row; other columns are
+// optional and read as empty strings when absent
+var requiredColumns = []Column{ColumnCountryISO2, ColumnSwiftCode, ColumnBankName, ColumnCountryName}
+
+// Layout is the order of the columns in the source spreadsheet. Files
+// written in this order under the Header names are read back unchanged.
+var Layout = []Column{ColumnCountryISO2, ColumnSwiftCode, ColumnBankName, ColumnAddress, ColumnCountryName}
+
+// Header returns the header row of Layout, naming each column by its first
+// default alias
+func Header() []string {
+	header := make([]string, len(Layout))
+	for i, column := range Layout {
+		header[i] = DefaultAliases[column][0]
+	}
+	return header
+}
+
+// Record returns the cells of code in Layout order
+func Record(code models.SwiftCode) []string {
+	record := make([]string, len(Layout))
+	for i, column := range Layout {
+		switch column {
+		case ColumnSwiftCode:
+			record[i] = code.SwiftCode
+		case ColumnCountryISO2:
+			record[i] = code.CountryISO2
+		case ColumnCountryName:
+			record[i] = code.CountryName
+		case ColumnBankName:
+			record[i] = code.BankName
+		case ColumnAddress:
+			record[i] = code.Address
+		}
+	}
+	return record
+}
+
+// columnMap records the position and header name of each known column
+type columnMap struct {
+	index  map[Column]int
+	header []string
+}
+
+// newColumnMap locates every column in the header row. Aliases override
+// DefaultAliases per column.
+func newColumnMap(header []string, aliases map[Column][]string) (columnMap, error) {
+	positions := make(map[string]int, len(header))
+	for i, cell := range header {
+		name := normalizeHeader(cell)
+		if _, seen := positions[name]; !seen && name != "" {
+			positions[name] = i
+		}
+	}
+
+	columns := columnMap{index: make(map[Column]int), header: header}
+	for column, defaults := range DefaultAliases {
+		names := defaults
+		if custom, ok := aliases[column]; ok {
+			names = custom
+		}
+		for _, name := range names {
+			if i, ok := positions[normalizeHeader(name)]; ok {
+				columns.index[column] = i
+				break
+			}
+		}
+	}
+
+	for _, column := range requiredColumns {
+		if _, ok := columns.index[column]; !ok {
+			names := DefaultAliases[column]
+			if custom, ok := aliases[column]; ok {
+				names = custom
+			}
+			return columnMap{}, fmt.Errorf("%w: %s (accepted headers: %s)",
+				ErrMissingColumn, column, strings.Join(names, ", "))
+		}
+	}
+	return columns, nil
+}
+
+// value returns the cell for column, or "" if the row is too short or the
+// column is not mapped
+func (m columnMap) value(row []string, column Column) string {
+	i, ok := m.index[column]
+	if !ok || i >= len(row) {
+		return ""
+	}
+	return strings.TrimSpace(row[i])
+}
+
+// width returns the number of cells a row needs to hold every required column
+func (m columnMap) width() int {
+	width := 0
+	for _, column := range requiredColumns {
+		if m.index[column]+1 > width {
+			width = m.index[column] + 1
+		}
+	}
+	return width
+}
+
+// name returns the header of column as written in the source file
+func (m columnMap) name(column Column) string {
+	i, ok := m.index[column]
+	if !ok {
+		return string(column)
+	}
+	return strings.TrimSpace(m.header[i])
+}
+
+func normalizeHeader(s string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(s), " "))
+}
@@ -1,44 +1,270 @@
 package parser
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
 	"strings"
 
-	"github.com/xuri/excelize/v2"
-
 	"swift-parser/internal/models"
+	"swift-parser/pkg/validator"
 )
 
+// Options controls how an input file is read
+type Options struct {
+	// Format selects the reader. When empty it is detected from the file
+	// extension, falling back to the content.
+	Format Format
+	// Sheet is the name of the Excel sheet to parse. The first sheet is used
+	// when empty. It is ignored for CSV and TSV input.
+	Sheet string
+	// Aliases overrides the accepted header names for individual columns.
+	// Columns without an entry use DefaultAliases.
+	Aliases map[Column][]string
+
+	// Delimiter separates CSV or TSV fields. When zero it is a tab for TSV, a
+	// comma for .csv files and sniffed from the header line otherwise.
+	Delimiter rune
+	// LazyQuotes allows quotes to appear in unquoted fields and non-doubled
+	// quotes in quoted fields of CSV or TSV input.
+	LazyQuotes bool
+	// Encoding of CSV or TSV input. When empty a UTF-8 byte order mark or
+	// valid UTF-8 selects UTF-8, anything else is read as Windows-1252.
+	Encoding Encoding
+
+	// RecordAccepted lists every accepted row in Report.Accepted. Otherwise
+	// accepted rows are only counted, so a stream does not keep them.
+	RecordAccepted bool
+}
+
+// ParseExcelFile reads all SWIFT codes from the first sheet of an Excel file.
+// Rows that are too short or fail validation are left out.
 func ParseExcelFile(filePath string) ([]models.SwiftCode, error) {
-	f, err := excelize.OpenFile(filePath)
+	codes, _, err := ParseFile(filePath, Options{Format: FormatXLSX})
+	return codes, err
+}
+
+// ParseFile reads all SWIFT codes from an Excel, CSV or TSV file, along with
+// a row-level report
+func ParseFile(filePath string, opts Options) ([]models.SwiftCode, *Report, error) {
+	var swiftCodes []models.SwiftCode
+	report, err := Iterate(context.Background(), filePath, opts, func(code models.SwiftCode) error {
+		swiftCodes = append(swiftCodes, code)
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to open excel file: %w", err)
+		return nil, report, err
+	}
+	return swiftCodes, report, nil
+}
+
+// Iterate streams SWIFT codes from an Excel, CSV or TSV file, calling fn for
+// each accepted row without loading the whole sheet into memory. Columns are
+// located by name in the header row. The returned report records the outcome
+// of every row read, including when iteration stops early at the first error
+// returned by fn or when ctx is done.
+func Iterate(ctx context.Context, filePath string, opts Options, fn func(models.SwiftCode) error) (*Report, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return &Report{}, fmt.Errorf("failed to open file: %w", err)
 	}
 	defer f.Close()
 
-	sheetName := f.GetSheetList()[0]
-	rows, err := f.GetRows(sheetName)
+	return IterateReader(ctx, f, filepath.Base(filePath), opts, fn)
+}
+
+// IterateReader is like Iterate but reads from r. name is the original file
+// name, used to detect the format and to label rows in the report.
+func IterateReader(ctx context.Context, r io.Reader, name string, opts Options, fn func(models.SwiftCode) error) (*Report, error) {
+	report := &Report{}
+
+	source, err := openSource(r, name, opts)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get rows: %w", err)
+		return report, err
 	}
+	defer source.Close()
 
-	var swiftCodes []models.SwiftCode
-	for i := 1; i < len(rows); i++ {
-		row := rows[i]
-		if len(row) < 7 {
+	var p *rowParser
+	for source.Next() {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+
+		row, err := source.Columns()
+		if err != nil {
+			return report, fmt.Errorf("%s: failed to read row %d: %w", source.Name(), source.Row(), err)
+		}
+
+		if p == nil {
+			if isBlank(row) {
+				continue
+			}
+			columns, err := newColumnMap(row, opts.Aliases)
+			if err != nil {
+				return report, fmt.Errorf("%s: %w", source.Name(), err)
+			}
+			p = newRowParser(source.Name(), columns, report, opts.RecordAccepted)
 			continue
 		}
 
-		swiftCode := models.SwiftCode{
-			Address:       row[4],
-			BankName:      row[3],
-			CountryISO2:   strings.ToUpper(row[0]),
-			CountryName:   strings.ToUpper(row[6]),
-			IsHeadquarter: strings.HasSuffix(row[1], "XXX"),
-			SwiftCode:     row[1],
+		swiftCode, ok := p.parse(source.Row(), row)
+		if !ok {
+			continue
+		}
+		if err := fn(swiftCode); err != nil {
+			return report, err
+		}
+	}
+	if err := source.Err(); err != nil {
+		return report, fmt.Errorf("%s: failed to read rows: %w", source.Name(), err)
+	}
+	if p == nil {
+		return report, fmt.Errorf("%s: no header row found", source.Name())
+	}
+
+	return report, nil
+}
+
+// IterateBatches streams SWIFT codes like Iterate but delivers them in
+// slices of up to size codes. The slice is reused between calls, so fn must
+// not retain it.
+func IterateBatches(ctx context.Context, filePath string, opts Options, size int, fn func([]models.SwiftCode) error) (*Report, error) {
+	if size <= 0 {
+		return &Report{}, fmt.Errorf("batch size must be positive, got %d", size)
+	}
+
+	batch := make([]models.SwiftCode, 0, size)
+	report, err := Iterate(ctx, filePath, opts, func(code models.SwiftCode) error {
+		batch = append(batch, code)
+		if len(batch) < size {
+			return nil
+		}
+		err := fn(batch)
+		batch = batch[:0]
+		return err
+	})
+	if err != nil {
+		return report, err
+	}
+
+	if len(batch) > 0 {
+		return report, fn(batch)
+	}
+	return report, nil
+}
+
+// codeKey holds a validated SWIFT code without the string header, keeping
+// the duplicate check small: it is the only state kept per accepted row
+type codeKey [11]byte
+
+// rowParser converts data rows into SWIFT codes and records the outcome of
+// each row in a report
+type rowParser struct {
+	sheet   string
+	columns columnMap
+	report  *Report
+	record  bool              // list accepted rows, not only count them
+	seen    map[codeKey]int32 // row number where each code was first accepted
+}
+
+func newRowParser(sheet string, columns columnMap, report *Report, record bool) *rowParser {
+	return &rowParser{
+		sheet:   sheet,
+		columns: columns,
+		report:  report,
+		record:  record,
+		seen:    make(map[codeKey]int32),
+	}
+}
+
+// parse converts a data row into a SWIFT code, reporting false for rows that
+// were skipped or rejected
+func (p *rowParser) parse(rowNum int, row []string) (models.SwiftCode, bool) {
+	if isBlank(row) {
+		p.skip(rowNum, ReasonBlankRow, "")
+		return models.SwiftCode{}, false
+	}
+	if len(row) < p.columns.width() {
+		p.skip(rowNum, ReasonShortRow,
+			fmt.Sprintf("want at least %d cells, got %d", p.columns.width(), len(row)))
+		return models.SwiftCode{}, false
+	}
+
+	code := strings.ToUpper(p.columns.value(row, ColumnSwiftCode))
+	countryISO2 := strings.ToUpper(p.columns.value(row, ColumnCountryISO2))
+
+	bic, err := validator.ParseBIC(code)
+	if err != nil {
+		reason := ReasonInvalidBIC
+		var bicErr *validator.BICError
+		if errors.As(err, &bicErr) && bicErr.Rule == validator.RuleUnknownCountry {
+			reason = ReasonUnknownCountry
 		}
-		swiftCodes = append(swiftCodes, swiftCode)
+		p.reject(rowNum, ColumnSwiftCode, code, reason, err.Error())
+		return models.SwiftCode{}, false
+	}
+	if !validator.IsKnownCountry(countryISO2) {
+		p.reject(rowNum, ColumnCountryISO2, code, ReasonUnknownCountry,
+			fmt.Sprintf("%q is not an ISO 3166-1 country code", countryISO2))
+		return models.SwiftCode{}, false
+	}
+	if bic.CountryCode != countryISO2 {
+		p.reject(rowNum, ColumnCountryISO2, code, ReasonCountryMismatch,
+			fmt.Sprintf("code country %s does not match %s", bic.CountryCode, countryISO2))
+		return models.SwiftCode{}, false
+	}
+	var key codeKey
+	copy(key[:], code)
+	if first, ok := p.seen[key]; ok {
+		p.reject(rowNum, ColumnSwiftCode, code, ReasonDuplicate,
+			fmt.Sprintf("first seen in row %d", first))
+		return models.SwiftCode{}, false
+	}
+
+	p.seen[key] = int32(rowNum)
+	p.report.AcceptedCount++
+	if p.record {
+		p.report.Accepted = append(p.report.Accepted, AcceptedRow{Sheet: p.sheet, Row: rowNum, SwiftCode: code})
 	}
 
-	return swiftCodes, nil
+	return models.SwiftCode{
+		Address:       p.columns.value(row, ColumnAddress),
+		BankName:      p.columns.value(row, ColumnBankName),
+		CountryISO2:   countryISO2,
+		CountryName:   strings.ToUpper(p.columns.value(row, ColumnCountryName)),
+		IsHeadquarter: bic.IsHeadquarter(),
+		SwiftCode:     code,
+	}, true
+}
+
+func (p *rowParser) skip(rowNum int, reason Reason, detail string) {
+	p.report.Skipped = append(p.report.Skipped, RowIssue{
+		Sheet:  p.sheet,
+		Row:    rowNum,
+		Reason: reason,
+		Detail: detail,
+	})
+}
+
+func (p *rowParser) reject(rowNum int, column Column, code string, reason Reason, detail string) {
+	p.report.Rejected = append(p.report.Rejected, RowIssue{
+		Sheet:     p.sheet,
+		Row:       rowNum,
+		Column:    p.columns.name(column),
+		Reason:    reason,
+		Detail:    detail,
+		SwiftCode: code,
+	})
+}
+
+func isBlank(row []string) bool {
+	for _, cell := range row {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
 }
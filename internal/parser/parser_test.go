@@ -1,10 +1,15 @@
 package parser
 
 import (
+	"context"
+	"errors"
+	"os"
 	"path/filepath"
 	"strings"
 	"swift-parser/internal/models"
 	"testing"
+
+	"github.com/xuri/excelize/v2"
 )
 
 func TestParseExcelFile(t *testing.T) {
@@ -64,3 +69,303 @@ func TestParseExcelFile(t *testing.T) {
 		})
 	}
 }
+
+func TestIterate(t *testing.T) {
+	filePath := filepath.Join("testdata", "Interns_2025_SWIFT_CODES.xlsx")
+
+	t.Run("Stops on callback error", func(t *testing.T) {
+		stop := errors.New("stop")
+		calls := 0
+		_, err := Iterate(context.Background(), filePath, Options{}, func(models.SwiftCode) error {
+			calls++
+			return stop
+		})
+		if !errors.Is(err, stop) {
+			t.Errorf("want callback error, got %v", err)
+		}
+		if calls != 1 {
+			t.Errorf("want 1 call, got %d", calls)
+		}
+	})
+
+	t.Run("Stops on cancelled context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := Iterate(ctx, filePath, Options{}, func(models.SwiftCode) error { return nil })
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("want context.Canceled, got %v", err)
+		}
+	})
+}
+
+func TestIterateBatches(t *testing.T) {
+	filePath := filepath.Join("testdata", "Interns_2025_SWIFT_CODES.xlsx")
+
+	all, err := ParseExcelFile(filePath)
+	if err != nil {
+		t.Fatalf("ParseExcelFile() error = %v", err)
+	}
+
+	const size = 100
+	var sizes []int
+	_, err = IterateBatches(context.Background(), filePath, Options{}, size, func(batch []models.SwiftCode) error {
+		sizes = append(sizes, len(batch))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("IterateBatches() error = %v", err)
+	}
+
+	total := 0
+	for i, n := range sizes {
+		if i < len(sizes)-1 && n != size {
+			t.Errorf("batch %d: want %d codes, got %d", i, size, n)
+		}
+		total += n
+	}
+	if total != len(all) {
+		t.Errorf("want %d codes in total, got %d", len(all), total)
+	}
+}
+
+// writeExcel creates an Excel file in a temporary directory with one sheet
+// per entry in sheets, in the given order
+func writeExcel(t *testing.T, sheetNames []string, sheets map[string][][]string) string {
+	t.Helper()
+
+	f := excelize.NewFile()
+	defer f.Close()
+
+	for i, name := range sheetNames {
+		if i == 0 {
+			if err := f.SetSheetName("Sheet1", name); err != nil {
+				t.Fatalf("Failed to rename sheet: %v", err)
+			}
+		} else if _, err := f.NewSheet(name); err != nil {
+			t.Fatalf("Failed to create sheet: %v", err)
+		}
+
+		for r, row := range sheets[name] {
+			cell, _ := excelize.CoordinatesToCellName(1, r+1)
+			if err := f.SetSheetRow(name, cell, &row); err != nil {
+				t.Fatalf("Failed to write row: %v", err)
+			}
+		}
+	}
+
+	filePath := filepath.Join(t.TempDir(), "codes.xlsx")
+	if err := f.SaveAs(filePath); err != nil {
+		t.Fatalf("Failed to save excel file: %v", err)
+	}
+	return filePath
+}
+
+func TestParseFile(t *testing.T) {
+	reordered := [][]string{
+		{"Notes", "BIC", "Bank Name", "Country", "Country ISO2", "Address"},
+		{"first", "ALBPPLPWXXX", "ALIOR BANK", "Poland", "pl", "WARSZAWA"},
+		{"second", "ALBPPLPW001", "ALIOR BANK", "Poland", "pl", ""},
+	}
+	custom := [][]string{
+		{"CODE", "ISO", "INSTITUTION", "STATE"},
+		{"BREXPLPWXXX", "PL", "MBANK", "POLAND"},
+	}
+	filePath := writeExcel(t, []string{"Summary", "Reordered", "Custom"}, map[string][][]string{
+		"Summary":   {{"Generated report"}},
+		"Reordered": reordered,
+		"Custom":    custom,
+	})
+
+	t.Run("Columns located by header name", func(t *testing.T) {
+		codes, _, err := ParseFile(filePath, Options{Sheet: "Reordered"})
+		if err != nil {
+			t.Fatalf("ParseFile() error = %v", err)
+		}
+		if len(codes) != 2 {
+			t.Fatalf("want 2 codes, got %d", len(codes))
+		}
+		want := models.SwiftCode{
+			Address:       "WARSZAWA",
+			BankName:      "ALIOR BANK",
+			CountryISO2:   "PL",
+			CountryName:   "POLAND",
+			IsHeadquarter: true,
+			SwiftCode:     "ALBPPLPWXXX",
+		}
+		if codes[0] != want {
+			t.Errorf("want %+v, got %+v", want, codes[0])
+		}
+	})
+
+	t.Run("Custom aliases", func(t *testing.T) {
+		codes, _, err := ParseFile(filePath, Options{
+			Sheet: "Custom",
+			Aliases: map[Column][]string{
+				ColumnSwiftCode:   {"code"},
+				ColumnCountryISO2: {"iso"},
+				ColumnBankName:    {"institution"},
+				ColumnCountryName: {"state"},
+			},
+		})
+		if err != nil {
+			t.Fatalf("ParseFile() error = %v", err)
+		}
+		if len(codes) != 1 || codes[0].SwiftCode != "BREXPLPWXXX" || codes[0].BankName != "MBANK" {
+			t.Errorf("unexpected codes: %+v", codes)
+		}
+	})
+
+	t.Run("Missing required column", func(t *testing.T) {
+		_, _, err := ParseFile(filePath, Options{Sheet: "Custom"})
+		if !errors.Is(err, ErrMissingColumn) {
+			t.Errorf("want ErrMissingColumn, got %v", err)
+		}
+	})
+
+	t.Run("Unknown sheet", func(t *testing.T) {
+		if _, _, err := ParseFile(filePath, Options{Sheet: "Missing"}); err == nil {
+			t.Error("want error for unknown sheet, got nil")
+		}
+	})
+}
+
+func TestParseReport(t *testing.T) {
+	filePath := writeExcel(t, []string{"Codes"}, map[string][][]string{
+		"Codes": {
+			{"COUNTRY ISO2 CODE", "SWIFT CODE", "NAME", "ADDRESS", "COUNTRY NAME"},
+			{"PL", "ALBPPLPWXXX", "ALIOR BANK", "WARSZAWA", "POLAND"},
+			{"PL", "ALBPPLPW001"},
+			{"PL", "ALBP-LPWXXX", "BROKEN BANK", "", "POLAND"},
+			{"ZZ", "ALBPZZPWXXX", "NOWHERE BANK", "", "NOWHERE"},
+			{"DE", "ALBPPLPW002", "ALIOR BANK", "", "GERMANY"},
+			{"PL", "ALBPPLPWXXX", "ALIOR BANK", "WARSZAWA", "POLAND"},
+		},
+	})
+
+	codes, report, err := ParseFile(filePath, Options{RecordAccepted: true})
+	if err != nil {
+		t.Fatalf("ParseFile() error = %v", err)
+	}
+	if len(codes) != 1 || report.AcceptedCount != 1 || len(report.Accepted) != 1 {
+		t.Fatalf("want 1 accepted code, got %d codes and report %s", len(codes), report.Summary())
+	}
+	if report.Accepted[0].Row != 2 {
+		t.Errorf("want accepted row 2, got %d", report.Accepted[0].Row)
+	}
+	if _, counted, err := ParseFile(filePath, Options{}); err != nil || counted.AcceptedCount != 1 || counted.Accepted != nil {
+		t.Errorf("want accepted rows only counted by default, got %+v and %v", counted, err)
+	}
+
+	if len(report.Skipped) != 1 || report.Skipped[0].Reason != ReasonShortRow || report.Skipped[0].Row != 3 {
+		t.Errorf("want short row 3 skipped, got %+v", report.Skipped)
+	}
+
+	want := []RowIssue{
+		{Row: 4, Column: "SWIFT CODE", Reason: ReasonInvalidBIC},
+		{Row: 5, Column: "SWIFT CODE", Reason: ReasonUnknownCountry},
+		{Row: 6, Column: "COUNTRY ISO2 CODE", Reason: ReasonCountryMismatch},
+		{Row: 7, Column: "SWIFT CODE", Reason: ReasonDuplicate},
+	}
+	if len(report.Rejected) != len(want) {
+		t.Fatalf("want %d rejected rows, got %+v", len(want), report.Rejected)
+	}
+	for i, issue := range report.Rejected {
+		if issue.Sheet != "Codes" || issue.Row != want[i].Row || issue.Column != want[i].Column || issue.Reason != want[i].Reason {
+			t.Errorf("rejection %d: want %+v, got %+v", i, want[i], issue)
+		}
+	}
+}
+
+func TestParseDelimitedFile(t *testing.T) {
+	writeFile := func(t *testing.T, name, content string) string {
+		t.Helper()
+		filePath := filepath.Join(t.TempDir(), name)
+		if err := os.WriteFile(filePath, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write test file: %v", err)
+		}
+		return filePath
+	}
+
+	tests := []struct {
+		name     string
+		fileName string
+		content  string
+		opts     Options
+		want     models.SwiftCode
+	}{
+		{
+			name:     "CSV with BOM and quoted fields",
+			fileName: "codes.csv",
+			content:  "\xEF\xBB\xBFCOUNTRY ISO2 CODE,SWIFT CODE,NAME,ADDRESS,COUNTRY NAME\r\npl,ALBPPLPWXXX,ALIOR BANK,\"PROSTA 68, WARSZAWA\",Poland\r\n",
+			want: models.SwiftCode{
+				Address: "PROSTA 68, WARSZAWA", BankName: "ALIOR BANK", CountryISO2: "PL",
+				CountryName: "POLAND", IsHeadquarter: true, SwiftCode: "ALBPPLPWXXX",
+			},
+		},
+		{
+			name:     "TSV",
+			fileName: "codes.tsv",
+			content:  "SWIFT CODE\tCOUNTRY ISO2 CODE\tNAME\tCOUNTRY NAME\nALBPPLPW001\tPL\tALIOR BANK\tPOLAND\n",
+			want: models.SwiftCode{
+				BankName: "ALIOR BANK", CountryISO2: "PL", CountryName: "POLAND", SwiftCode: "ALBPPLPW001",
+			},
+		},
+		{
+			name:     "Windows-1252 with sniffed semicolon delimiter",
+			fileName: "codes.txt",
+			content:  "BIC;ISO2;BANK NAME;COUNTRY NAME\nBNPAFRPPXXX;FR;BANQUE G\xC9N\xC9RALE;FRANCE\n",
+			want: models.SwiftCode{
+				BankName: "BANQUE GÉNÉRALE", CountryISO2: "FR", CountryName: "FRANCE",
+				IsHeadquarter: true, SwiftCode: "BNPAFRPPXXX",
+			},
+		},
+		{
+			name:     "Explicit delimiter",
+			fileName: "codes.dat",
+			content:  "BIC|ISO2|BANK NAME|COUNTRY NAME\nBNPAFRPPXXX|FR|BNP PARIBAS|FRANCE\n",
+			opts:     Options{Format: FormatCSV, Delimiter: '|'},
+			want: models.SwiftCode{
+				BankName: "BNP PARIBAS", CountryISO2: "FR", CountryName: "FRANCE",
+				IsHeadquarter: true, SwiftCode: "BNPAFRPPXXX",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filePath := writeFile(t, tt.fileName, tt.content)
+
+			opts := tt.opts
+			opts.RecordAccepted = true
+			codes, report, err := ParseFile(filePath, opts)
+			if err != nil {
+				t.Fatalf("ParseFile() error = %v", err)
+			}
+			if len(codes) != 1 {
+				t.Fatalf("want 1 code, got %d (%s)", len(codes), report.Summary())
+			}
+			if codes[0] != tt.want {
+				t.Errorf("want %+v, got %+v", tt.want, codes[0])
+			}
+			if report.Accepted[0].Sheet != tt.fileName || report.Accepted[0].Row != 2 {
+				t.Errorf("want %s row 2, got %+v", tt.fileName, report.Accepted[0])
+			}
+		})
+	}
+
+	t.Run("Excel content detected without extension", func(t *testing.T) {
+		xlsx, err := os.ReadFile(filepath.Join("testdata", "Interns_2025_SWIFT_CODES.xlsx"))
+		if err != nil {
+			t.Fatalf("Failed to read test data: %v", err)
+		}
+		filePath := writeFile(t, "codes", string(xlsx))
+
+		codes, _, err := ParseFile(filePath, Options{})
+		if err != nil {
+			t.Fatalf("ParseFile() error = %v", err)
+		}
+		if len(codes) == 0 {
+			t.Error("want codes from sniffed Excel file")
+		}
+	})
+}
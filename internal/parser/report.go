@@ -0,0 +1,79 @@
+package parser
+
+import (
+	"fmt"
+)
+
+// Reason explains why a row was skipped or rejected
+type Reason string
+
+const (
+	ReasonBlankRow        Reason = "blank row"
+	ReasonShortRow        Reason = "short row"
+	ReasonInvalidBIC      Reason = "invalid BIC"
+	ReasonUnknownCountry  Reason = "unknown country"
+	ReasonCountryMismatch Reason = "country mismatch"
+	ReasonDuplicate       Reason = "duplicate code"
+)
+
+// AcceptedRow identifies a row that produced a SWIFT code
+type AcceptedRow struct {
+	Sheet     string `json:"sheet"`
+	Row       int    `json:"row"`
+	SwiftCode string `json:"swiftCode"`
+}
+
+// RowIssue describes a row that was skipped or rejected. Column holds the
+// header of the offending cell, when the issue concerns a single cell.
+type RowIssue struct {
+	Sheet     string `json:"sheet"`
+	Row       int    `json:"row"`
+	Column    string `json:"column,omitempty"`
+	Reason    Reason `json:"reason"`
+	Detail    string `json:"detail,omitempty"`
+	SwiftCode string `json:"swiftCode,omitempty"`
+}
+
+func (i RowIssue) String() string {
+	location := fmt.Sprintf("%s row %d", i.Sheet, i.Row)
+	if i.Column != "" {
+		location += fmt.Sprintf(" column %q", i.Column)
+	}
+	if i.Detail != "" {
+		return fmt.Sprintf("%s: %s: %s", location, i.Reason, i.Detail)
+	}
+	return fmt.Sprintf("%s: %s", location, i.Reason)
+}
+
+// Report is the row-level audit trail of a parse. Skipped rows hold no data
+// (blank or short rows); rejected rows hold data that failed validation.
+// Accepted rows are counted, and listed only with Options.RecordAccepted.
+type Report struct {
+	AcceptedCount int           `json:"acceptedCount"`
+	Accepted      []AcceptedRow `json:"accepted,omitempty"`
+	Skipped       []RowIssue    `json:"skipped"`
+	Rejected      []RowIssue    `json:"rejected"`
+}
+
+// Summary returns a one-line count of accepted, skipped and rejected rows
+func (r *Report) Summary() string {
+	return fmt.Sprintf("%d accepted, %d skipped, %d rejected",
+		r.AcceptedCount, len(r.Skipped), len(r.Rejected))
+}
+
+// HasRejections reports whether any row failed validation
+func (r *Report) HasRejections() bool {
+	return len(r.Rejected) > 0
+}
+
+// RejectedCodes returns the SWIFT codes of the rejected rows that have one.
+// They are present in the source even though they were not accepted.
+func (r *Report) RejectedCodes() []string {
+	var codes []string
+	for _, issue := range r.Rejected {
+		if issue.SwiftCode != "" {
+			codes = append(codes, issue.SwiftCode)
+		}
+	}
+	return codes
+}
@@ -0,0 +1,268 @@
+package parser
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/xuri/excelize/v2"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/transform"
+)
+
+// Format identifies the layout of an input file
+type Format string
+
+const (
+	FormatAuto Format = ""
+	FormatXLSX Format = "xlsx"
+	FormatCSV  Format = "csv"
+	FormatTSV  Format = "tsv"
+)
+
+// Encoding identifies the character encoding of a CSV or TSV file
+type Encoding string
+
+const (
+	EncodingAuto        Encoding = ""
+	EncodingUTF8        Encoding = "utf-8"
+	EncodingWindows1252 Encoding = "windows-1252"
+)
+
+// sniffSize is the number of leading bytes inspected to detect the format,
+// encoding and delimiter of an input
+const sniffSize = 64 * 1024
+
+var (
+	zipMagic = []byte("PK\x03\x04")
+	utf8BOM  = []byte("\xEF\xBB\xBF")
+)
+
+// rowSource yields the rows of one sheet or delimited file
+type rowSource interface {
+	// Name identifies the sheet or file in diagnostics
+	Name() string
+	// Row returns the 1-based row or line number of the current row
+	Row() int
+	Next() bool
+	Columns() ([]string, error)
+	Err() error
+	Close() error
+}
+
+// openSource detects the format of r and returns a row source for it. name
+// is the file name, used for format detection by extension and diagnostics.
+func openSource(r io.Reader, name string, opts Options) (rowSource, error) {
+	br := bufio.NewReaderSize(r, sniffSize)
+	head, err := br.Peek(sniffSize)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, bufio.ErrBufferFull) {
+		return nil, fmt.Errorf("failed to read input: %w", err)
+	}
+
+	format := opts.Format
+	if format == FormatAuto {
+		format = detectFormat(name, head)
+	}
+
+	switch format {
+	case FormatXLSX:
+		return openExcelSource(br, opts.Sheet)
+	case FormatCSV, FormatTSV:
+		return openDelimitedSource(br, head, name, format, opts)
+	default:
+		return nil, fmt.Errorf("unsupported format %q", format)
+	}
+}
+
+// detectFormat picks a format from the file extension, falling back to the
+// content for unknown extensions
+func detectFormat(name string, head []byte) Format {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".xlsx", ".xlsm", ".xltx", ".xltm":
+		return FormatXLSX
+	case ".csv":
+		return FormatCSV
+	case ".tsv", ".tab":
+		return FormatTSV
+	}
+
+	if bytes.HasPrefix(head, zipMagic) {
+		return FormatXLSX
+	}
+	if sniffDelimiter(head) == '\t' {
+		return FormatTSV
+	}
+	return FormatCSV
+}
+
+// sniffDelimiter returns the most frequent candidate delimiter in the first
+// line of head, defaulting to a comma
+func sniffDelimiter(head []byte) rune {
+	line, _, _ := bytes.Cut(head, []byte("\n"))
+	best, bestCount := ',', 0
+	for _, candidate := range []rune{',', '\t', ';', '|'} {
+		if n := bytes.Count(line, []byte(string(candidate))); n > bestCount {
+			best, bestCount = candidate, n
+		}
+	}
+	return best
+}
+
+// excelSource reads rows from one sheet of an Excel workbook
+type excelSource struct {
+	file  *excelize.File
+	rows  *excelize.Rows
+	sheet string
+	row   int
+}
+
+func openExcelSource(r io.Reader, sheet string) (*excelSource, error) {
+	f, err := excelize.OpenReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open excel file: %w", err)
+	}
+
+	sheetName, err := selectSheet(f, sheet)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	rows, err := f.Rows(sheetName)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to get rows: %w", err)
+	}
+	return &excelSource{file: f, rows: rows, sheet: sheetName}, nil
+}
+
+func (s *excelSource) Name() string               { return s.sheet }
+func (s *excelSource) Row() int                   { return s.row }
+func (s *excelSource) Columns() ([]string, error) { return s.rows.Columns() }
+func (s *excelSource) Err() error                 { return s.rows.Error() }
+
+func (s *excelSource) Next() bool {
+	s.row++
+	return s.rows.Next()
+}
+
+func (s *excelSource) Close() error {
+	s.rows.Close()
+	return s.file.Close()
+}
+
+// selectSheet returns the requested sheet name, or the first sheet if none
+// was requested
+func selectSheet(f *excelize.File, sheet string) (string, error) {
+	sheets := f.GetSheetList()
+	if len(sheets) == 0 {
+		return "", fmt.Errorf("excel file has no sheets")
+	}
+	if sheet == "" {
+		return sheets[0], nil
+	}
+	if !slices.Contains(sheets, sheet) {
+		return "", fmt.Errorf("sheet %q not found (available: %s)", sheet, strings.Join(sheets, ", "))
+	}
+	return sheet, nil
+}
+
+// delimitedSource reads rows from a CSV or TSV file
+type delimitedSource struct {
+	reader *csv.Reader
+	name   string
+	row    []string
+	err    error
+}
+
+func openDelimitedSource(r io.Reader, head []byte, name string, format Format, opts Options) (*delimitedSource, error) {
+	encoding := opts.Encoding
+	if encoding == EncodingAuto {
+		encoding = detectEncoding(head, len(head) == sniffSize)
+	}
+
+	if bytes.HasPrefix(head, utf8BOM) {
+		if _, err := io.CopyN(io.Discard, r, int64(len(utf8BOM))); err != nil {
+			return nil, fmt.Errorf("failed to read input: %w", err)
+		}
+		encoding = EncodingUTF8
+	}
+
+	switch encoding {
+	case EncodingUTF8:
+	case EncodingWindows1252:
+		r = transform.NewReader(r, charmap.Windows1252.NewDecoder())
+	default:
+		return nil, fmt.Errorf("unsupported encoding %q", encoding)
+	}
+
+	delimiter := opts.Delimiter
+	if delimiter == 0 {
+		switch {
+		case format == FormatTSV:
+			delimiter = '\t'
+		case strings.EqualFold(filepath.Ext(name), ".csv"):
+			delimiter = ','
+		default:
+			delimiter = sniffDelimiter(head)
+		}
+	}
+
+	reader := csv.NewReader(r)
+	reader.Comma = delimiter
+	reader.LazyQuotes = opts.LazyQuotes
+	reader.FieldsPerRecord = -1
+
+	return &delimitedSource{reader: reader, name: filepath.Base(name)}, nil
+}
+
+func (s *delimitedSource) Name() string { return s.name }
+
+// Row returns the line on which the current record starts
+func (s *delimitedSource) Row() int {
+	line, _ := s.reader.FieldPos(0)
+	return line
+}
+
+func (s *delimitedSource) Next() bool {
+	if s.err != nil {
+		return false
+	}
+	s.row, s.err = s.reader.Read()
+	return s.err == nil
+}
+
+func (s *delimitedSource) Columns() ([]string, error) { return s.row, nil }
+
+func (s *delimitedSource) Err() error {
+	if errors.Is(s.err, io.EOF) {
+		return nil
+	}
+	return s.err
+}
+
+func (s *delimitedSource) Close() error { return nil }
+
+// detectEncoding reports UTF-8 when head is valid UTF-8 and Windows-1252
+// otherwise. Only the sniffed prefix is inspected; when it was cut short, a
+// multi-byte character split at the end is ignored.
+func detectEncoding(head []byte, truncated bool) Encoding {
+	if utf8.Valid(head) {
+		return EncodingUTF8
+	}
+	if truncated {
+		for i := 1; i < utf8.UTFMax && i < len(head); i++ {
+			if utf8.Valid(head[:len(head)-i]) {
+				return EncodingUTF8
+			}
+		}
+	}
+	return EncodingWindows1252
+}
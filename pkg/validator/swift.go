@@ -1,14 +1,132 @@
 package validator
 
 import (
-	"regexp"
+	"fmt"
 )
 
+// Rule identifies the ISO 9362 structural rule a BIC failed
+type Rule string
+
+const (
+	RuleLength          Rule = "length"
+	RuleInstitutionCode Rule = "institution code"
+	RuleCountryCode     Rule = "country code"
+	RuleUnknownCountry  Rule = "unknown country"
+	RuleLocationCode    Rule = "location code"
+	RuleBranchCode      Rule = "branch code"
+)
+
+// BICError reports which structural rule a BIC failed
+type BICError struct {
+	Code   string
+	Rule   Rule
+	Reason string
+}
+
+func (e *BICError) Error() string {
+	return fmt.Sprintf("invalid BIC %q: %s: %s", e.Code, e.Rule, e.Reason)
+}
+
+// BIC is a parsed ISO 9362 business identifier code
+type BIC struct {
+	InstitutionCode string // 4 letters identifying the bank
+	CountryCode     string // ISO 3166-1 alpha-2 country code
+	LocationCode    string // 2 alphanumeric characters
+	BranchCode      string // 3 alphanumeric characters, "XXX" for the primary office
+
+	IsTest           bool // location code ends in '0'
+	IsPassive        bool // location code ends in '1'
+	IsReverseBilling bool // location code ends in '2'
+}
+
+// BIC8 returns the first 8 characters, identifying the institution's location
+func (b BIC) BIC8() string {
+	return b.InstitutionCode + b.CountryCode + b.LocationCode
+}
+
+// String returns the 11 character form of the BIC
+func (b BIC) String() string {
+	return b.BIC8() + b.BranchCode
+}
+
+// IsHeadquarter reports whether the BIC identifies the primary office
+func (b BIC) IsHeadquarter() bool {
+	return b.BranchCode == "XXX"
+}
+
+// ParseBIC parses an 8 or 11 character upper-case BIC. An 8 character BIC is
+// treated as the primary office, with branch code "XXX". Failures are
+// returned as *BICError.
+func ParseBIC(code string) (BIC, error) {
+	fail := func(rule Rule, reason string) (BIC, error) {
+		return BIC{}, &BICError{Code: code, Rule: rule, Reason: reason}
+	}
+
+	if len(code) != 8 && len(code) != 11 {
+		return fail(RuleLength, fmt.Sprintf("must be 8 or 11 characters, got %d", len(code)))
+	}
+
+	bic := BIC{
+		InstitutionCode: code[0:4],
+		CountryCode:     code[4:6],
+		LocationCode:    code[6:8],
+		BranchCode:      "XXX",
+	}
+	if len(code) == 11 {
+		bic.BranchCode = code[8:11]
+	}
+
+	if !isUpperAlpha(bic.InstitutionCode) {
+		return fail(RuleInstitutionCode, "must be 4 upper-case letters")
+	}
+	if !isUpperAlpha(bic.CountryCode) {
+		return fail(RuleCountryCode, "must be 2 upper-case letters")
+	}
+	if !IsKnownCountry(bic.CountryCode) {
+		return fail(RuleUnknownCountry, fmt.Sprintf("%q is not an ISO 3166-1 country code", bic.CountryCode))
+	}
+	if !isUpperAlnum(bic.LocationCode) {
+		return fail(RuleLocationCode, "must be 2 upper-case letters or digits")
+	}
+	if !isUpperAlnum(bic.BranchCode) {
+		return fail(RuleBranchCode, "must be 3 upper-case letters or digits")
+	}
+	if bic.BranchCode[0] == 'X' && bic.BranchCode != "XXX" {
+		return fail(RuleBranchCode, "may only start with 'X' when it is \"XXX\"")
+	}
+
+	switch bic.LocationCode[1] {
+	case '0':
+		bic.IsTest = true
+	case '1':
+		bic.IsPassive = true
+	case '2':
+		bic.IsReverseBilling = true
+	}
+
+	return bic, nil
+}
+
+// ValidateSWIFT reports whether swiftCode is a structurally valid BIC
 func ValidateSWIFT(swiftCode string) bool {
-	if len(swiftCode) != 8 && len(swiftCode) != 11 {
-		return false
+	_, err := ParseBIC(swiftCode)
+	return err == nil
+}
+
+func isUpperAlpha(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < 'A' || s[i] > 'Z' {
+			return false
+		}
 	}
+	return true
+}
 
-	swiftRegex := regexp.MustCompile(`^[A-Z]{6}[A-Z0-9]{2}([A-Z0-9]{3})?$`)
-	return swiftRegex.MatchString(swiftCode)
+func isUpperAlnum(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if (s[i] < 'A' || s[i] > 'Z') && (s[i] < '0' || s[i] > '9') {
+			return false
+		}
+	}
+	return true
 }
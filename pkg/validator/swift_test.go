@@ -0,0 +1,95 @@
+package validator
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseBIC(t *testing.T) {
+	tests := []struct {
+		name     string
+		code     string
+		wantRule Rule
+		validate func(t *testing.T, bic BIC)
+	}{
+		{
+			name: "Headquarter BIC11",
+			code: "BCECCLRRXXX",
+			validate: func(t *testing.T, bic BIC) {
+				if bic.InstitutionCode != "BCEC" || bic.CountryCode != "CL" || bic.LocationCode != "RR" || bic.BranchCode != "XXX" {
+					t.Errorf("unexpected parts: %+v", bic)
+				}
+				if !bic.IsHeadquarter() {
+					t.Error("want headquarter")
+				}
+			},
+		},
+		{
+			name: "BIC8 is treated as headquarter",
+			code: "BCECCLRR",
+			validate: func(t *testing.T, bic BIC) {
+				if bic.String() != "BCECCLRRXXX" {
+					t.Errorf("want BCECCLRRXXX, got %s", bic.String())
+				}
+			},
+		},
+		{
+			name: "Test BIC",
+			code: "TESTTR00XXX",
+			validate: func(t *testing.T, bic BIC) {
+				if !bic.IsTest || bic.IsPassive || bic.IsReverseBilling {
+					t.Errorf("want test flag only, got %+v", bic)
+				}
+			},
+		},
+		{
+			name: "Passive participant",
+			code: "BCHICLR10R2",
+			validate: func(t *testing.T, bic BIC) {
+				if !bic.IsPassive || bic.IsHeadquarter() {
+					t.Errorf("want passive branch, got %+v", bic)
+				}
+			},
+		},
+		{
+			name: "Reverse billing",
+			code: "ABCDPLP2XXX",
+			validate: func(t *testing.T, bic BIC) {
+				if !bic.IsReverseBilling {
+					t.Errorf("want reverse billing flag, got %+v", bic)
+				}
+			},
+		},
+		{name: "Too short", code: "ABCDPL", wantRule: RuleLength},
+		{name: "Lowercase institution", code: "abcdPLPWXXX", wantRule: RuleInstitutionCode},
+		{name: "Digit in country", code: "ABCDP1PWXXX", wantRule: RuleCountryCode},
+		{name: "Unknown country", code: "ABCDZZPWXXX", wantRule: RuleUnknownCountry},
+		{name: "Invalid location", code: "ABCDPLP-XXX", wantRule: RuleLocationCode},
+		{name: "Invalid branch", code: "ABCDPLPW0_1", wantRule: RuleBranchCode},
+		{name: "Branch starting with X", code: "ABCDPLPWX12", wantRule: RuleBranchCode},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bic, err := ParseBIC(tt.code)
+
+			if tt.wantRule != "" {
+				var bicErr *BICError
+				if !errors.As(err, &bicErr) {
+					t.Fatalf("want *BICError, got %v", err)
+				}
+				if bicErr.Rule != tt.wantRule {
+					t.Errorf("want rule %q, got %q", tt.wantRule, bicErr.Rule)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("ParseBIC(%q) error = %v", tt.code, err)
+			}
+			if tt.validate != nil {
+				tt.validate(t, bic)
+			}
+		})
+	}
+}